@@ -0,0 +1,280 @@
+package tong
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ming3000/tong/common"
+)
+
+// defaultMultipartMemory is the memory used to parse a multipart form,
+// the same as http.Request.ParseMultipartForm does by default.
+const defaultMultipartMemory = 32 << 20
+
+// Binder binds the data of a request to a value.
+type Binder interface {
+	Bind(i interface{}, c *Context) error
+}
+
+// DefaultBinder binds the path params, the query params for the requests
+// without body and the body by its Content-Type.
+// The fields of the struct are matched by the tags param, query, header and
+// form, json and xml for the body, or their names if they have no tag.
+type DefaultBinder struct{}
+
+// Bind implements the Binder interface.
+// the params are bound to a struct only, other values like maps and
+// slices are left to the body decoder.
+func (b *DefaultBinder) Bind(i interface{}, c *Context) error {
+	if v := reflect.ValueOf(i); v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return b.BindBody(i, c)
+	} // if>
+	if err := c.BindPath(i); err != nil {
+		return err
+	} // if>
+	switch c.request.Method {
+	case http.MethodGet, http.MethodDelete, http.MethodHead:
+		if err := c.BindQuery(i); err != nil {
+			return err
+		} // if>>
+	} // switch>
+	return b.BindBody(i, c)
+}
+
+// BindBody binds the request body by its Content-Type.
+func (b *DefaultBinder) BindBody(i interface{}, c *Context) error {
+	r := c.request
+	if r.ContentLength == 0 || r.Body == nil || r.Body == http.NoBody {
+		return nil
+	} // if>
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get(common.HeaderContentType))
+	switch {
+	case mediaType == common.MIMEApplicationJSON || strings.HasSuffix(mediaType, "+json"):
+		if err := json.NewDecoder(r.Body).Decode(i); err != nil {
+			return c.bodyError("bind json", err)
+		} // if>>
+	case mediaType == common.MIMETextXML || mediaType == common.MIMEApplicationXML:
+		if err := xml.NewDecoder(r.Body).Decode(i); err != nil {
+			return c.bodyError("bind xml", err)
+		} // if>>
+	case mediaType == common.MIMEApplicationForm:
+		if err := r.ParseForm(); err != nil {
+			return c.bodyError("bind form", err)
+		} // if>>
+		return bindData(i, r.PostForm, "form")
+	case mediaType == common.MIMEMultipartForm:
+		form, err := c.MultipartForm()
+		if err != nil {
+			return err
+		} // if>>
+		return bindData(i, form.Value, "form")
+	default:
+		return ErrUnsupportedMediaType
+	} // switch>
+	return nil
+}
+
+// Bind binds the request data to i by the Binder of Tong,
+// then validates it by the Validator of Tong if there is one.
+func (c *Context) Bind(i interface{}) error {
+	if err := c.tong.Binder.Bind(i, c); err != nil {
+		return err
+	} // if>
+	return c.Validate(i)
+}
+
+// Validate validates i by the Validator of Tong.
+func (c *Context) Validate(i interface{}) error {
+	if c.tong.Validator == nil {
+		return nil
+	} // if>
+	return c.tong.Validator.Validate(i)
+}
+
+// BindPath binds the path params to i by the param tags.
+func (c *Context) BindPath(i interface{}) error {
+	if len(c.pnames) == 0 {
+		return nil
+	} // if>
+	data := make(map[string][]string, len(c.pnames))
+	for k, name := range c.pnames {
+		data[name] = []string{c.pvalues[k]}
+	} // for>
+	return bindData(i, data, "param")
+}
+
+// BindQuery binds the query params to i by the query tags.
+func (c *Context) BindQuery(i interface{}) error {
+	return bindData(i, c.request.URL.Query(), "query")
+}
+
+// BindHeader binds the request headers to i by the header tags.
+func (c *Context) BindHeader(i interface{}) error {
+	return bindData(i, c.request.Header, "header")
+}
+
+// bindData sets the fields of the struct pointed by ptr from data,
+// a map[string]string or map[string][]string is filled with data.
+func bindData(ptr interface{}, data map[string][]string, tag string) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New("tong: bind to a non-pointer value")
+	} // if>
+	v = v.Elem()
+
+	switch m := ptr.(type) {
+	case *map[string][]string:
+		if *m == nil {
+			*m = make(map[string][]string, len(data))
+		} // if>>
+		for k, vs := range data {
+			(*m)[k] = vs
+		} // for>>
+		return nil
+	case *map[string]string:
+		if *m == nil {
+			*m = make(map[string]string, len(data))
+		} // if>>
+		for k, vs := range data {
+			if len(vs) > 0 {
+				(*m)[k] = vs[0]
+			} // if>>>
+		} // for>>
+		return nil
+	} // switch>
+
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("tong: bind to unsupported type %s", v.Type())
+	} // if>
+	return bindStruct(v, data, tag)
+}
+
+func bindStruct(v reflect.Value, data map[string][]string, tag string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fv := v.Field(i)
+		if field.PkgPath != "" && !(field.Anonymous && fv.Kind() == reflect.Struct) {
+			// the exported fields of an unexported embedded struct are still bound
+			continue
+		} // if>>
+
+		name, tagged := field.Tag.Lookup(tag)
+		if name == "-" {
+			continue
+		} // if>>
+		name = strings.Split(name, ",")[0]
+		if !tagged || name == "" {
+			// nested and embedded structs without tag are bound field by field
+			if fv.Kind() == reflect.Struct && !isScalarType(fv.Type()) {
+				if err := bindStruct(fv, data, tag); err != nil {
+					return err
+				} // if>>>>
+				continue
+			} // if>>>
+			name = field.Name
+		} // if>>
+
+		values, ok := data[name]
+		if !ok && tag == "header" {
+			values, ok = data[textproto.CanonicalMIMEHeaderKey(name)]
+		} // if>>
+		if !ok || len(values) == 0 {
+			continue
+		} // if>>
+		if err := setField(fv, values); err != nil {
+			return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid value of %s", name)).
+				WithInternal(fmt.Errorf("tong: bind field %s: %v", name, err))
+		} // if>>
+	} // for>
+	return nil
+}
+
+// setField sets a field from the values, a slice field takes all of them.
+func setField(fv reflect.Value, values []string) error {
+	if fv.Kind() == reflect.Slice && !isScalarType(fv.Type()) {
+		slice := reflect.MakeSlice(fv.Type(), len(values), len(values))
+		for i, s := range values {
+			if err := setValue(slice.Index(i), s); err != nil {
+				return err
+			} // if>>>
+		} // for>>
+		fv.Set(slice)
+		return nil
+	} // if>
+	return setValue(fv, values[0])
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// isScalarType reports whether a value of type t is set from a single string
+// by its encoding.TextUnmarshaler, like time.Time.
+func isScalarType(t reflect.Type) bool {
+	return reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// setValue sets a scalar value from s.
+func setValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		} // if>>
+		return setValue(v.Elem(), s)
+	} // if>
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	} // if>
+
+	switch v.Interface().(type) {
+	case time.Duration:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		} // if>>
+		v.SetInt(int64(d))
+		return nil
+	} // switch>
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		} // if>>
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		} // if>>
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		} // if>>
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		} // if>>
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	} // switch>
+	return nil
+}
@@ -0,0 +1,197 @@
+package tong
+
+import (
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type bindPage struct {
+	Page int `query:"page" form:"page"`
+	Size int `query:"size" form:"size"`
+}
+
+type bindUser struct {
+	ID      int           `param:"id" json:"-"`
+	Name    string        `json:"name" xml:"name" form:"name" query:"name"`
+	Tags    []string      `json:"tags" xml:"tag" form:"tag" query:"tag"`
+	Active  *bool         `json:"active" form:"active"`
+	Since   time.Time     `form:"since"`
+	Timeout time.Duration `form:"timeout"`
+	Token   string        `header:"X-Token"`
+	bindPage
+}
+
+func bindRequest(t *testing.T, method, target, contentType, body string) (*bindUser, error) {
+	tong := newTestTong()
+	var user bindUser
+	var bindErr error
+	tong.Add(method, "/user/:id", func(c *Context) error {
+		if bindErr = c.Bind(&user); bindErr == nil {
+			bindErr = c.BindHeader(&user)
+		}
+		return nil
+	})
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("X-Token", "secret")
+	tong.ServeHTTP(httptest.NewRecorder(), req)
+	return &user, bindErr
+}
+
+func TestBind(t *testing.T) {
+	yes := true
+	since, _ := time.Parse(time.RFC3339, "2020-01-02T03:04:05Z")
+
+	user, err := bindRequest(t, http.MethodPost, "/user/7?page=3", "application/json; charset=UTF-8",
+		`{"name":"tong","tags":["a","b"],"active":true}`)
+	want := &bindUser{ID: 7, Name: "tong", Tags: []string{"a", "b"}, Active: &yes, Token: "secret"}
+	if err != nil || !reflect.DeepEqual(user, want) {
+		t.Errorf("json: %+v, %v", user, err)
+	}
+
+	user, err = bindRequest(t, http.MethodPut, "/user/7", "text/xml",
+		`<user><name>tong</name><tag>a</tag><tag>b</tag></user>`)
+	if err != nil || user.Name != "tong" || !reflect.DeepEqual(user.Tags, []string{"a", "b"}) {
+		t.Errorf("xml: %+v, %v", user, err)
+	}
+
+	form := url.Values{"name": {"tong"}, "tag": {"a", "b"}, "active": {"true"}, "page": {"2"},
+		"since": {"2020-01-02T03:04:05Z"}, "timeout": {"1m30s"}}
+	user, err = bindRequest(t, http.MethodPost, "/user/7", "application/x-www-form-urlencoded", form.Encode())
+	want = &bindUser{ID: 7, Name: "tong", Tags: []string{"a", "b"}, Active: &yes, Since: since,
+		Timeout: 90 * time.Second, Token: "secret", bindPage: bindPage{Page: 2}}
+	if err != nil || !reflect.DeepEqual(user, want) {
+		t.Errorf("form: %+v, %v", user, err)
+	}
+
+	var body strings.Builder
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField("name", "tong")
+	_ = mw.WriteField("size", "20")
+	_ = mw.Close()
+	user, err = bindRequest(t, http.MethodPost, "/user/7", mw.FormDataContentType(), body.String())
+	if err != nil || user.Name != "tong" || user.Size != 20 {
+		t.Errorf("multipart: %+v, %v", user, err)
+	}
+
+	user, err = bindRequest(t, http.MethodGet, "/user/7?name=tong&tag=a&tag=b&page=4", "", "")
+	if err != nil || user.Name != "tong" || len(user.Tags) != 2 || user.Page != 4 {
+		t.Errorf("query: %+v, %v", user, err)
+	}
+
+	if _, err = bindRequest(t, http.MethodPost, "/user/7", "application/x-www-form-urlencoded", "page=x"); err == nil {
+		t.Error("form with an invalid int did not fail")
+	}
+	if _, err = bindRequest(t, http.MethodPost, "/user/7", "application/msgpack", "\x81"); err != ErrUnsupportedMediaType {
+		t.Errorf("msgpack: err = %v", err)
+	}
+}
+
+type validAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+func TestBindNonStruct(t *testing.T) {
+	tong := newTestTong()
+	var m map[string]interface{}
+	var ids []int
+	tong.POST("/map/:id", func(c *Context) error {
+		return c.Bind(&m)
+	})
+	tong.POST("/slice/:id", func(c *Context) error {
+		return c.Bind(&ids)
+	})
+	for path, body := range map[string]string{"/map/7?page=1": `{"name":"tong"}`, "/slice/7": `[1,2]`} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		tong.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: code = %d, body = %s", path, rec.Code, rec.Body.String())
+		}
+	}
+	if m["name"] != "tong" || len(m) != 1 || !reflect.DeepEqual(ids, []int{1, 2}) {
+		t.Errorf("unexpected values %v %v", m, ids)
+	}
+}
+
+type validSignup struct {
+	Name      string          `json:"name" validate:"required,min=3,max=8"`
+	Email     string          `json:"email" validate:"required,email"`
+	Age       *int            `json:"age" validate:"omitempty,min=18"`
+	Role      string          `json:"role" validate:"oneof=admin user"`
+	Website   string          `json:"website" validate:"omitempty,url"`
+	Code      string          `json:"code" validate:"len=4,numeric"`
+	Address   validAddress    `json:"address"`
+	Others    []*validAddress `json:"others"`
+	Nickname  string          `validate:"slug"`
+	internals string
+}
+
+func TestValidate(t *testing.T) {
+	v := NewValidator()
+	v.RegisterRule("slug", stringRule(func(s string) bool { return !strings.Contains(s, " ") }))
+
+	age := 16
+	err := v.Validate(&validSignup{
+		Name:     "to",
+		Email:    "not an email",
+		Age:      &age,
+		Role:     "root",
+		Website:  "example.com",
+		Code:     "12a4",
+		Others:   []*validAddress{{City: "Beijing"}, {}},
+		Nickname: "a b",
+	})
+	fields, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("Validate: err = %v", err)
+	}
+	var got []string
+	for _, fe := range fields {
+		got = append(got, fe.Field+":"+fe.Rule)
+	}
+	want := "name:min,email:email,age:min,role:oneof,website:url,code:numeric,address.city:required,others[1].city:required,Nickname:slug"
+	if strings.Join(got, ",") != want {
+		t.Errorf("Validate fields = %v, want %v", got, want)
+	}
+
+	valid := validSignup{Name: "tong", Email: "tong@example.com", Role: "user", Code: "1234",
+		Address: validAddress{City: "Shanghai"}}
+	if err := v.Validate(valid); err != nil {
+		t.Errorf("Validate valid value: %v", err)
+	}
+	if err := v.Validate(&struct {
+		X string `validate:"nope"`
+	}{}); err == nil || err.Error() != `tong: unknown validation rule "nope" of field X` {
+		t.Errorf("Validate unknown rule: %v", err)
+	}
+}
+
+func TestBindValidationErrorResponse(t *testing.T) {
+	tong := newTestTong()
+	tong.POST("/signup", func(c *Context) error {
+		var s validSignup
+		if err := c.Bind(&s); err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, "ok")
+	})
+	tong.Validator.(*DefaultValidator).RegisterRule("slug", ruleRequired)
+
+	req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(`{"name":"tong","email":"x"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	tong.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), `{"field":"email","rule":"email","message":"email must be a valid email address"}`) {
+		t.Errorf("code = %d, body = %s", rec.Code, rec.Body.String())
+	}
+}
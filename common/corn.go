@@ -1,6 +1,9 @@
 package common
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // Job is an interface for job to do.
 type Job interface {
@@ -8,6 +11,12 @@ type Job interface {
 	Run() bool
 }
 
+// ContextJob is a Job whose context is canceled when the Cron is stopped.
+type ContextJob interface {
+	// true to decline the step period, false to stay
+	RunContext(ctx context.Context) bool
+}
+
 /// Cron keeps track of a job,
 // invoking associated job's Run() method.
 type Cron struct {
@@ -18,6 +27,9 @@ type Cron struct {
 	prev          time.Time
 	next          time.Time
 	job           Job
+	contextJob    ContextJob
+	ctx           context.Context
+	cancel        context.CancelFunc
 	wait          chan bool
 	stop          chan struct{}
 	running       bool
@@ -40,6 +52,12 @@ func (c *Cron) Do(job Job) *Cron {
 	return c
 }
 
+// DoContext sets the job which is canceled by Stop.
+func (c *Cron) DoContext(job ContextJob) *Cron {
+	c.contextJob = job
+	return c
+}
+
 // Start the Cron in its own go-routine,
 // or do nothing if already started.
 func (c *Cron) Start() {
@@ -47,6 +65,7 @@ func (c *Cron) Start() {
 		return
 	}
 	c.running = true
+	c.ctx, c.cancel = context.WithCancel(context.Background())
 	go c.run()
 }
 
@@ -55,6 +74,7 @@ func (c *Cron) Stop() {
 	if !c.running {
 		return
 	}
+	c.cancel()
 	c.stop <- struct{}{}
 	c.running = false
 }
@@ -66,6 +86,7 @@ func (c *Cron) schedule(period time.Duration) {
 }
 
 func (c *Cron) run() {
+	ctx := c.ctx
 	ticker := time.NewTicker(1 * time.Second)
 	var ifWait bool
 	go func() {
@@ -73,7 +94,7 @@ func (c *Cron) run() {
 			select {
 			case <-ticker.C:
 				if !ifWait {
-					go c.runJob()
+					go c.runJob(ctx)
 				}
 				continue
 			case <-c.stop:
@@ -87,12 +108,23 @@ func (c *Cron) run() {
 	}()
 }
 
-func (c *Cron) runJob() {
+// runJob tells run that the job is running and done by wait,
+// or gives up once ctx is canceled by Stop, since run is not receiving.
+func (c *Cron) runJob(ctx context.Context) {
 	if time.Now().After(c.next) {
-		c.wait <- true
+		select {
+		case c.wait <- true:
+		case <-ctx.Done():
+			return
+		}
 		// set-schedule before this job is done
 		c.schedule(c.period)
-		ifDecline := c.job.Run()
+		var ifDecline bool
+		if c.contextJob != nil {
+			ifDecline = c.contextJob.RunContext(ctx)
+		} else {
+			ifDecline = c.job.Run()
+		}
 		if ifDecline {
 			next := c.period + c.stepPeriod
 			if next >= c.maxPeriod {
@@ -104,7 +136,10 @@ func (c *Cron) runJob() {
 			// re-schedule after this job is done
 			c.schedule(c.initialPeriod)
 		}
-		c.wait <- false
+		select {
+		case c.wait <- false:
+		case <-ctx.Done():
+		}
 	}
 }
 
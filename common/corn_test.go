@@ -1 +1,46 @@
 package common
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+type blockingJob struct {
+	started, canceled chan struct{}
+}
+
+func (j blockingJob) RunContext(ctx context.Context) bool {
+	close(j.started)
+	<-ctx.Done()
+	close(j.canceled)
+	return false
+}
+
+func TestCronStopCancelsContextJob(t *testing.T) {
+	goroutines := runtime.NumGoroutine()
+	job := blockingJob{started: make(chan struct{}), canceled: make(chan struct{})}
+	c := NewCron(time.Millisecond, time.Millisecond, time.Millisecond).DoContext(job)
+	c.Start()
+	select {
+	case <-job.started:
+	case <-time.After(3 * time.Second):
+		t.Fatal("the job did not start")
+	} // select>
+
+	c.Stop()
+	select {
+	case <-job.canceled:
+	case <-time.After(time.Second):
+		t.Fatal("the job was not canceled by Stop")
+	} // select>
+
+	// the goroutines of the Cron and the job are gone after Stop
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > goroutines; {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines leaked", runtime.NumGoroutine()-goroutines)
+		} // if>>
+		time.Sleep(10 * time.Millisecond)
+	} // for>
+}
@@ -5,6 +5,7 @@ const (
 	charsetUTF8                    = "charset=UTF-8"
 	MIMEApplicationJSON            = "application/json"
 	MIMEApplicationJSONCharsetUTF8 = MIMEApplicationJSON + "; " + charsetUTF8
+	MIMEApplicationXML             = "application/xml"
 	MIMETextXML                    = "text/xml"
 	MIMETextXMLCharsetUTF8         = MIMETextXML + "; " + charsetUTF8
 	MIMEApplicationForm            = "application/x-www-form-urlencoded"
@@ -15,6 +16,7 @@ const (
 	MIMETextPlain                  = "text/plain"
 	MIMETextPlainCharsetUTF8       = MIMETextPlain + "; " + charsetUTF8
 	MIMEMultipartForm              = "multipart/form-data"
+	MIMETextEventStream            = "text/event-stream"
 )
 
 // --- HTTP Header Fields
@@ -23,13 +25,18 @@ const (
 	HeaderAcceptEncoding      = "Accept-Encoding"
 	HeaderAllow               = "Allow"
 	HeaderAuthorization       = "Authorization"
+	HeaderCacheControl        = "Cache-Control"
+	HeaderConnection          = "Connection"
 	HeaderContentDisposition  = "Content-Disposition"
 	HeaderContentEncoding     = "Content-Encoding"
 	HeaderContentLength       = "Content-Length"
 	HeaderContentType         = "Content-Type"
 	HeaderCookie              = "Cookie"
+	HeaderETag                = "ETag"
 	HeaderSetCookie           = "Set-Cookie"
 	HeaderIfModifiedSince     = "If-Modified-Since"
+	HeaderIfNoneMatch         = "If-None-Match"
+	HeaderLastEventID         = "Last-Event-ID"
 	HeaderLastModified        = "Last-Modified"
 	HeaderLocation            = "Location"
 	HeaderUpgrade             = "Upgrade"
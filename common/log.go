@@ -27,7 +27,12 @@ func NewLogger(fileName string, fileMaxSize int, fileMaxExpire int, prefix strin
 		MaxAge:     fileMaxExpire,
 		MaxBackups: 1,
 	}
-	stdLogger := log.New(io.MultiWriter(os.Stdout, lj), prefix, log.Ldate|log.Ltime|log.Lshortfile)
+	return NewWriterLogger(io.MultiWriter(os.Stdout, lj), prefix, debug)
+}
+
+// NewWriterLogger returns a Logger writing to w only, e.g. ioutil.Discard for tests.
+func NewWriterLogger(w io.Writer, prefix string, debug bool) *Logger {
+	stdLogger := log.New(w, prefix, log.Ldate|log.Ltime|log.Lshortfile)
 	return &Logger{
 		stdLogger:        stdLogger,
 		debug:            debug,
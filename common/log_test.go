@@ -19,6 +19,6 @@ func thirdLevel(l *Logger) {
 }
 
 func TestLogger_Debug(t *testing.T) {
-	l := NewDefaultLogger()
+	l := NewDefaultLogger(true)
 	firstlevel(l)
 }
@@ -1,21 +1,30 @@
 package tong
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/ming3000/tong/common"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Context is context for every goroutine
 type Context struct {
+	tong         *Tong
 	request      *http.Request
 	response     *Response
 	path         string
+	pnames       []string
+	pvalues      []string
 	handler      HandlerFunc
 	logger       *common.Logger
 	requestCache common.Cache
+	maxMemory    int64
+	onRelease    []func()
+	formErr      error
 }
 
 // $--- utils ---
@@ -23,9 +32,14 @@ func (c *Context) Reset(r *http.Request, w http.ResponseWriter, logger *common.L
 	c.request = r
 	c.response.Reset(w)
 	c.path = ""
+	c.pnames = nil
+	c.pvalues = c.pvalues[:0]
 	c.handler = NotFoundHandler
 	c.logger = logger
 	c.requestCache = cache
+	c.maxMemory = 0
+	c.onRelease = c.onRelease[:0]
+	c.formErr = nil
 }
 
 func (c *Context) Redirect(code int, url string) error {
@@ -37,6 +51,16 @@ func (c *Context) Redirect(code int, url string) error {
 	return nil
 }
 
+// SetRequest sets the request, e.g. after a middleware derived a new one.
+func (c *Context) SetRequest(r *http.Request) {
+	c.request = r
+}
+
+// SetResponse sets the response, e.g. after a middleware wrapped the writer.
+func (c *Context) SetResponse(r *Response) {
+	c.response = r
+}
+
 // $--- Getter ---
 func (c *Context) Request() *http.Request {
 	return c.request
@@ -46,10 +70,54 @@ func (c *Context) Response() *Response {
 	return c.response
 }
 
+// Path returns the registered route pattern of the request, e.g. /user/:id.
 func (c *Context) Path() string {
 	return c.path
 }
 
+// Param returns the value of the path parameter by name,
+// or an empty string if the matched route has no such parameter.
+func (c *Context) Param(name string) string {
+	for i, n := range c.pnames {
+		if n == name && i < len(c.pvalues) {
+			return c.pvalues[i]
+		} // if>>
+	} // for>
+	return ""
+}
+
+// ParamInt returns the path parameter by name as an int,
+// or defaultValue if it is missing or not an int. with an <int> constraint
+// on the route the param is always an int.
+func (c *Context) ParamInt(name string, defaultValue int) int {
+	ret, err := strconv.Atoi(c.Param(name))
+	if err != nil {
+		return defaultValue
+	} // if>
+	return ret
+}
+
+// ParamUUID returns the path parameter by name in lower case if it is a UUID,
+// or defaultValue if it is not. with a <uuid> constraint on the route the
+// param is always a UUID.
+func (c *Context) ParamUUID(name string, defaultValue string) string {
+	value := c.Param(name)
+	if !isUUID(value) {
+		return defaultValue
+	} // if>
+	return strings.ToLower(value)
+}
+
+// ParamNames returns the path parameter names of the matched route.
+func (c *Context) ParamNames() []string {
+	return c.pnames
+}
+
+// ParamValues returns the path parameter values in the order of ParamNames.
+func (c *Context) ParamValues() []string {
+	return c.pvalues
+}
+
 func (c *Context) Handler() HandlerFunc {
 	return c.handler
 }
@@ -62,6 +130,64 @@ func (c *Context) Logger() *common.Logger {
 	return c.logger
 }
 
+// $--- context.Context ---
+// Context implements context.Context by the context of the request,
+// so it can be passed to the calls which should stop with the request.
+// it must not be used after the handler returns, since it is reused.
+var _ context.Context = (*Context)(nil)
+
+func (c *Context) requestContext() context.Context {
+	if c.request == nil {
+		return context.Background()
+	} // if>
+	return c.request.Context()
+}
+
+// Deadline returns the deadline of the request context.
+func (c *Context) Deadline() (time.Time, bool) {
+	return c.requestContext().Deadline()
+}
+
+// Done is closed when the request is canceled, e.g. the client is gone or
+// the timeout of WithTimeout passed.
+func (c *Context) Done() <-chan struct{} {
+	return c.requestContext().Done()
+}
+
+// Err returns why Done is closed.
+func (c *Context) Err() error {
+	return c.requestContext().Err()
+}
+
+// Value returns the value stored by Set for a string key,
+// or the value of the request context.
+func (c *Context) Value(key interface{}) interface{} {
+	if k, ok := key.(string); ok && c.requestCache != nil {
+		if v := c.requestCache.Get(k); v != nil {
+			return v
+		} // if>>
+	} // if>
+	return c.requestContext().Value(key)
+}
+
+// Set stores a value of the request, it is visible to Get and Value.
+func (c *Context) Set(key string, value interface{}) {
+	c.requestCache.Set(key, value)
+}
+
+// Get returns the value stored by Set, or nil.
+func (c *Context) Get(key string) interface{} {
+	return c.requestCache.Get(key)
+}
+
+// WithTimeout sets a timeout on the request context, the returned cancel
+// should be deferred to release its resources.
+func (c *Context) WithTimeout(timeout time.Duration) context.CancelFunc {
+	ctx, cancel := context.WithTimeout(c.requestContext(), timeout)
+	c.request = c.request.WithContext(ctx)
+	return cancel
+}
+
 // $--- Writer ---
 func (c *Context) WriteContentType(value string) {
 	head := c.response.Header()
@@ -71,8 +197,8 @@ func (c *Context) WriteContentType(value string) {
 }
 
 func (c *Context) Blob(code int, contentType string, data []byte) error {
-	c.response.WriteHeader(code)
 	c.WriteContentType(contentType)
+	c.response.WriteHeader(code)
 	_, err := c.response.Write(data)
 	return err
 }
@@ -92,72 +218,40 @@ func (c *Context) String(code int, value string) error {
 }
 
 // $--- Query Reader ---
+// the readers below are the lenient readers of QueryReader and FormReader,
+// kept for the existing handlers.
 func (c *Context) QueryInt(key string, defaultValue int) int {
-	value := c.request.URL.Query().Get(key)
-	if value == "" {
-		return defaultValue
-	} // if>
-
-	ret, err := strconv.Atoi(value)
-	if err != nil {
-		return defaultValue
-	} // if>
-	return ret
+	return c.QueryReader().Int(key, defaultValue)
 }
 
 func (c *Context) QueryFloat(key string, defaultValue float64) float64 {
-	value := c.request.URL.Query().Get(key)
-	if value == "" {
-		return defaultValue
-	} // if>
-
-	ret, err := strconv.ParseFloat(value, 64)
-	if err != nil {
-		return defaultValue
-	} // if>
-	return ret
+	return c.QueryReader().Float(key, defaultValue)
 }
 
 func (c *Context) QueryString(key string, defaultValue string) string {
-	value := c.request.URL.Query().Get(key)
-	if value == "" {
-		return defaultValue
-	} // if>
-	return value
+	return c.QueryReader().String(key, defaultValue)
 }
 
 // $--- Post Reader ---
 func (c *Context) PostInt(key string, defaultValue int) int {
-	value := c.request.PostFormValue(key)
-	if value == "" {
-		return defaultValue
-	} // if>
-
-	ret, err := strconv.Atoi(value)
-	if err != nil {
-		return defaultValue
-	} // if>
-	return ret
+	return c.postReader().Int(key, defaultValue)
 }
 
 func (c *Context) PostFloat(key string, defaultValue float64) float64 {
-	value := c.request.PostFormValue(key)
-	if value == "" {
-		return defaultValue
-	} // if>
-
-	ret, err := strconv.ParseFloat(value, 64)
-	if err != nil {
-		return defaultValue
-	} // if>
-	return ret
+	return c.postReader().Float(key, defaultValue)
 }
 
 func (c *Context) PostString(key string, defaultValue string) string {
-	value := c.request.PostFormValue(key)
-	if value == "" {
-		return defaultValue
-	} // if>
+	return c.postReader().String(key, defaultValue)
+}
 
-	return value
+// postReader is FormReader for the readers above, which return the default
+// value if the body can not be read, so the error is logged instead of lost.
+// the strict readers of FormReader still return it later in the request.
+func (c *Context) postReader() ValueReader {
+	r := c.FormReader()
+	if r.err != nil {
+		c.logger.DebugFormat("%s %s: %v", c.request.Method, c.request.URL.Path, r.err)
+	} // if>
+	return r
 }
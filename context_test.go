@@ -0,0 +1,80 @@
+package tong
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type ctxKey struct{}
+
+// lookup stands for a call taking a context.Context, e.g. a DB query.
+func lookup(ctx context.Context, key interface{}) (interface{}, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		return ctx.Value(key), nil
+	} // select>
+}
+
+func TestContextValues(t *testing.T) {
+	tong := newTestTong()
+	tong.GET("/", func(c *Context) error {
+		v, _ := lookup(c, "user")
+		w, _ := lookup(c, ctxKey{})
+		return c.String(http.StatusOK, v.(string)+" "+w.(string))
+	}, func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			c.Set("user", "tong")
+			c.SetRequest(c.Request().WithContext(context.WithValue(c.Request().Context(), ctxKey{}, "request")))
+			return next(c)
+		}
+	})
+	rec := httptest.NewRecorder()
+	tong.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Body.String() != "tong request" {
+		t.Fatalf("unexpected body %q", rec.Body.String())
+	} // if>
+
+	// the values do not leak into the next request
+	tong.GET("/next", func(c *Context) error {
+		if c.Get("user") != nil || c.Value("user") != nil {
+			t.Error("value of the previous request is visible")
+		} // if>>
+		return nil
+	})
+	tong.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/next", nil))
+}
+
+func TestContextCancellation(t *testing.T) {
+	tong := newTestTong()
+	tong.GET("/slow", func(c *Context) error {
+		cancel := c.WithTimeout(10 * time.Millisecond)
+		defer cancel()
+		if _, ok := c.Deadline(); !ok {
+			t.Error("expected a deadline")
+		} // if>>
+		<-c.Done()
+		_, err := lookup(c, "user")
+		return err
+	})
+	tong.GET("/gone", func(c *Context) error {
+		<-c.Done()
+		return c.Err()
+	})
+
+	var errs []error
+	tong.HTTPErrorHandler = func(c *Context, err error) {
+		errs = append(errs, err)
+	}
+	tong.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tong.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/gone", nil).WithContext(ctx))
+	if len(errs) != 2 || errs[0] != context.DeadlineExceeded || errs[1] != context.Canceled {
+		t.Fatalf("unexpected errors %v", errs)
+	} // if>
+}
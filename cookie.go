@@ -0,0 +1,174 @@
+package tong
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ming3000/tong/common"
+)
+
+var (
+	// ErrInvalidCookie is returned when a signed or encrypted cookie is not
+	// verified by any of the CookieKeys.
+	ErrInvalidCookie = errors.New("tong: invalid cookie")
+	// ErrNoCookieKeys is returned when signing or encrypting without CookieKeys.
+	ErrNoCookieKeys = errors.New("tong: no CookieKeys")
+)
+
+// Cookie returns the cookie of the request by name, or http.ErrNoCookie.
+func (c *Context) Cookie(name string) (*http.Cookie, error) {
+	return c.request.Cookie(name)
+}
+
+// Cookies returns all the cookies of the request.
+func (c *Context) Cookies() []*http.Cookie {
+	return c.request.Cookies()
+}
+
+// SetCookie adds the Set-Cookie header. unless in debug mode or with
+// DisableSecureCookies, the cookie is HttpOnly, SameSite=Lax if not set
+// and Secure over https.
+func (c *Context) SetCookie(cookie *http.Cookie) {
+	c.setCookie(cookie, true)
+}
+
+// SetScriptCookie is SetCookie for a cookie read by scripts, e.g. a CSRF token,
+// it is not made HttpOnly but gets the other defaults.
+func (c *Context) SetScriptCookie(cookie *http.Cookie) {
+	c.setCookie(cookie, false)
+}
+
+func (c *Context) setCookie(cookie *http.Cookie, httpOnly bool) {
+	if !c.tong.Debug && !c.tong.DisableSecureCookies {
+		secured := *cookie
+		secured.HttpOnly = secured.HttpOnly || httpOnly
+		if secured.SameSite == 0 {
+			secured.SameSite = http.SameSiteLaxMode
+		} // if>>
+		if c.request.TLS != nil || c.request.Header.Get(common.HeaderXForwardedProto) == "https" {
+			secured.Secure = true
+		} // if>>
+		cookie = &secured
+	} // if>
+	http.SetCookie(c.response, cookie)
+}
+
+// $--- signed cookie ---
+
+// SetSignedCookie sets the cookie with its value signed by the first of
+// CookieKeys, the value is readable by the client but can not be changed.
+func (c *Context) SetSignedCookie(cookie *http.Cookie) error {
+	keys := c.tong.CookieKeys
+	if len(keys) == 0 {
+		return ErrNoCookieKeys
+	} // if>
+	signed := *cookie
+	value := base64.RawURLEncoding.EncodeToString([]byte(cookie.Value))
+	signed.Value = value + "." + base64.RawURLEncoding.EncodeToString(cookieMAC(keys[0], cookie.Name, value))
+	c.SetCookie(&signed)
+	return nil
+}
+
+// SignedCookie returns the value of the signed cookie by name,
+// it is verified by any of CookieKeys, so the old keys still work after rotation.
+func (c *Context) SignedCookie(name string) (string, error) {
+	cookie, err := c.Cookie(name)
+	if err != nil {
+		return "", err
+	} // if>
+	i := strings.LastIndexByte(cookie.Value, '.')
+	if i < 0 {
+		return "", ErrInvalidCookie
+	} // if>
+	value := cookie.Value[:i]
+	mac, err := base64.RawURLEncoding.DecodeString(cookie.Value[i+1:])
+	if err != nil {
+		return "", ErrInvalidCookie
+	} // if>
+	for _, key := range c.tong.CookieKeys {
+		if hmac.Equal(mac, cookieMAC(key, name, value)) {
+			decoded, err := base64.RawURLEncoding.DecodeString(value)
+			if err != nil {
+				return "", ErrInvalidCookie
+			} // if>>>
+			return string(decoded), nil
+		} // if>>
+	} // for>
+	return "", ErrInvalidCookie
+}
+
+// cookieMAC signs the name with the value, so a value can not be moved to another cookie.
+func cookieMAC(key []byte, name, value string) []byte {
+	h := hmac.New(sha256.New, key)
+	io.WriteString(h, name+"|"+value)
+	return h.Sum(nil)
+}
+
+// $--- encrypted cookie ---
+
+// SetEncryptedCookie sets the cookie with its value encrypted by AES-GCM
+// with the first of CookieKeys, the value is neither readable nor changeable.
+func (c *Context) SetEncryptedCookie(cookie *http.Cookie) error {
+	keys := c.tong.CookieKeys
+	if len(keys) == 0 {
+		return ErrNoCookieKeys
+	} // if>
+	aead, err := cookieAEAD(keys[0])
+	if err != nil {
+		return err
+	} // if>
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(cookie.Value)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	} // if>
+	encrypted := *cookie
+	encrypted.Value = base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(cookie.Value), []byte(cookie.Name)))
+	c.SetCookie(&encrypted)
+	return nil
+}
+
+// EncryptedCookie returns the decrypted value of the cookie by name,
+// it is decrypted by any of CookieKeys, so the old keys still work after rotation.
+func (c *Context) EncryptedCookie(name string) (string, error) {
+	cookie, err := c.Cookie(name)
+	if err != nil {
+		return "", err
+	} // if>
+	data, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		return "", ErrInvalidCookie
+	} // if>
+	for _, key := range c.tong.CookieKeys {
+		aead, err := cookieAEAD(key)
+		if err != nil {
+			return "", err
+		} // if>>
+		if len(data) < aead.NonceSize() {
+			return "", ErrInvalidCookie
+		} // if>>
+		value, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(name))
+		if err == nil {
+			return string(value), nil
+		} // if>>
+	} // for>
+	return "", ErrInvalidCookie
+}
+
+// cookieAEAD derives the AES-256 key from the cookie key,
+// so the same keys of any length are used for signing and encryption.
+func cookieAEAD(key []byte) (cipher.AEAD, error) {
+	derived := sha256.Sum256(append([]byte("tong cookie encryption|"), key...))
+	block, err := aes.NewCipher(derived[:])
+	if err != nil {
+		return nil, err
+	} // if>
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,108 @@
+package tong
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// roundTrip sets the cookies by set of from and reads them by get of to in the next request.
+func roundTrip(t *testing.T, from, to *Tong, set func(c *Context) error, get func(c *Context) error) *http.Response {
+	from.GET("/set", set)
+	to.GET("/get", get)
+	rec := httptest.NewRecorder()
+	from.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/set", nil))
+	resp := rec.Result()
+	req := httptest.NewRequest(http.MethodGet, "/get", nil)
+	for _, cookie := range resp.Cookies() {
+		req.AddCookie(cookie)
+	} // for>
+	rec = httptest.NewRecorder()
+	to.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Body.String())
+	} // if>
+	return resp
+}
+
+func TestCookie(t *testing.T) {
+	tong := newTestTong()
+	tong.Debug = false
+	resp := roundTrip(t, tong, tong, func(c *Context) error {
+		c.SetCookie(&http.Cookie{Name: "lang", Value: "zh"})
+		c.SetScriptCookie(&http.Cookie{Name: "csrf", Value: "token"})
+		return nil
+	}, func(c *Context) error {
+		cookie, err := c.Cookie("lang")
+		if err != nil || cookie.Value != "zh" || len(c.Cookies()) != 2 {
+			t.Errorf("unexpected cookie %v %v", cookie, err)
+		} // if>>
+		if _, err := c.Cookie("missing"); err != http.ErrNoCookie {
+			t.Errorf("expected http.ErrNoCookie, got %v", err)
+		} // if>>
+		return nil
+	})
+	headers := resp.Header["Set-Cookie"]
+	if len(headers) != 2 || !strings.Contains(headers[0], "HttpOnly") || !strings.Contains(headers[0], "SameSite=Lax") {
+		t.Fatalf("expected secure defaults, got %q", headers)
+	} // if>
+	// a cookie read by scripts is not HttpOnly but keeps the other defaults
+	if strings.Contains(headers[1], "HttpOnly") || !strings.Contains(headers[1], "SameSite=Lax") {
+		t.Fatalf("unexpected csrf cookie %q", headers[1])
+	} // if>
+}
+
+func TestSignedAndEncryptedCookie(t *testing.T) {
+	set := func(c *Context) error {
+		if err := c.SetSignedCookie(&http.Cookie{Name: "user", Value: "tong.1"}); err != nil {
+			return err
+		} // if>>
+		return c.SetEncryptedCookie(&http.Cookie{Name: "token", Value: "secret"})
+	}
+	var signed, encrypted string
+	get := func(c *Context) error {
+		cookie, _ := c.Cookie("token")
+		if strings.Contains(cookie.Value, "secret") {
+			t.Error("the encrypted cookie is readable")
+		} // if>>
+		signed, _ = c.SignedCookie("user")
+		encrypted, _ = c.EncryptedCookie("token")
+		return nil
+	}
+
+	old := newTestTong()
+	old.CookieKeys = [][]byte{[]byte("old key")}
+	roundTrip(t, old, old, set, get)
+	if signed != "tong.1" || encrypted != "secret" {
+		t.Fatalf("unexpected values %q %q", signed, encrypted)
+	} // if>
+
+	// the cookies of the old key are accepted after rotation
+	rotated := newTestTong()
+	rotated.CookieKeys = [][]byte{[]byte("new key"), []byte("old key")}
+	signed, encrypted = "", ""
+	roundTrip(t, old, rotated, set, get)
+	if signed != "tong.1" || encrypted != "secret" {
+		t.Fatalf("unexpected values after rotation %q %q", signed, encrypted)
+	} // if>
+
+	// tampered or moved values are rejected
+	rotated.GET("/tampered", func(c *Context) error {
+		if _, err := c.SignedCookie("user"); err != ErrInvalidCookie {
+			t.Errorf("expected ErrInvalidCookie for the signed cookie, got %v", err)
+		} // if>>
+		if _, err := c.EncryptedCookie("token"); err != ErrInvalidCookie {
+			t.Errorf("expected ErrInvalidCookie for the encrypted cookie, got %v", err)
+		} // if>>
+		return nil
+	})
+	req := httptest.NewRequest(http.MethodGet, "/tampered", nil)
+	req.AddCookie(&http.Cookie{Name: "user", Value: "YWRtaW4.c2ln"})
+	req.AddCookie(&http.Cookie{Name: "token", Value: "c2VjcmV0c2VjcmV0c2VjcmV0c2VjcmV0"})
+	rotated.ServeHTTP(httptest.NewRecorder(), req)
+
+	if err := newTestTong().NewContext(req, httptest.NewRecorder()).SetSignedCookie(&http.Cookie{Name: "user"}); err != ErrNoCookieKeys {
+		t.Fatalf("expected ErrNoCookieKeys, got %v", err)
+	} // if>
+}
@@ -0,0 +1,59 @@
+package tong
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// HTTPError is an error with the HTTP status code to answer,
+// Message is sent to the client while Internal is only logged.
+type HTTPError struct {
+	Code     int         `json:"-"`
+	Message  interface{} `json:"message"`
+	Internal error       `json:"-"`
+}
+
+// $--- sentinel errors ---
+var (
+	ErrBadRequest            = NewHTTPError(http.StatusBadRequest)
+	ErrUnauthorized          = NewHTTPError(http.StatusUnauthorized)
+	ErrForbidden             = NewHTTPError(http.StatusForbidden)
+	ErrNotFound              = NewHTTPError(http.StatusNotFound)
+	ErrMethodNotAllowed      = NewHTTPError(http.StatusMethodNotAllowed)
+	ErrNotAcceptable         = NewHTTPError(http.StatusNotAcceptable)
+	ErrRequestEntityTooLarge = NewHTTPError(http.StatusRequestEntityTooLarge)
+	ErrUnsupportedMediaType  = NewHTTPError(http.StatusUnsupportedMediaType)
+	ErrUnprocessableEntity   = NewHTTPError(http.StatusUnprocessableEntity)
+	ErrTooManyRequests       = NewHTTPError(http.StatusTooManyRequests)
+	ErrInternalServerError   = NewHTTPError(http.StatusInternalServerError)
+	ErrServiceUnavailable    = NewHTTPError(http.StatusServiceUnavailable)
+)
+
+// NewHTTPError creates an HTTPError with the status code,
+// the message is the status text of code if it is not given.
+func NewHTTPError(code int, message ...interface{}) *HTTPError {
+	he := &HTTPError{Code: code, Message: http.StatusText(code)}
+	if len(message) > 0 {
+		he.Message = message[0]
+	} // if>
+	return he
+}
+
+// Error implements the error interface.
+func (he *HTTPError) Error() string {
+	if he.Internal == nil {
+		return fmt.Sprintf("code=%d, message=%v", he.Code, he.Message)
+	} // if>
+	return fmt.Sprintf("code=%d, message=%v, internal=%v", he.Code, he.Message, he.Internal)
+}
+
+// WithInternal returns a copy of the HTTPError with the internal error,
+// so the sentinel errors can be used as templates, e.g. ErrNotFound.WithInternal(err).
+func (he *HTTPError) WithInternal(err error) *HTTPError {
+	return &HTTPError{Code: he.Code, Message: he.Message, Internal: err}
+}
+
+// Unwrap returns the internal error for errors.Is and errors.As.
+func (he *HTTPError) Unwrap() error {
+	return he.Internal
+}
@@ -0,0 +1,55 @@
+package tong
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDefaultHTTPErrorHandler(t *testing.T) {
+	dbErr := errors.New("dial tcp 10.0.0.1:3306: connection refused")
+	tong := newTestTong()
+	tong.GET("/missing", func(c *Context) error {
+		return ErrNotFound.WithInternal(dbErr)
+	})
+	tong.GET("/teapot", func(c *Context) error {
+		return NewHTTPError(http.StatusTeapot, "short and stout")
+	})
+	tong.GET("/internal", func(c *Context) error {
+		return dbErr
+	})
+	tong.GET("/written", func(c *Context) error {
+		_ = c.String(http.StatusAccepted, "partial")
+		return dbErr
+	})
+
+	for _, tc := range []struct {
+		debug        bool
+		path, accept string
+		code         int
+		body         string
+	}{
+		{false, "/missing", "", http.StatusNotFound, "Not Found"},
+		{true, "/missing", "", http.StatusNotFound, "Not Found: " + dbErr.Error()},
+		{false, "/teapot", "application/json", http.StatusTeapot, `{"message":"short and stout"}` + "\n"},
+		{false, "/internal", "text/html", http.StatusInternalServerError, "Internal Server Error"},
+		{true, "/internal", "application/json", http.StatusInternalServerError, `{"message":"` + dbErr.Error() + `"}` + "\n"},
+		{false, "/written", "", http.StatusAccepted, "partial"},
+		{false, "/nowhere", "application/json", http.StatusNotFound, `{"message":"Not Found"}` + "\n"},
+	} {
+		tong.Debug = tc.debug
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Header.Set("Accept", tc.accept)
+		rec := httptest.NewRecorder()
+		tong.ServeHTTP(rec, req)
+		if rec.Code != tc.code || rec.Body.String() != tc.body {
+			t.Errorf("debug %v GET %s: code = %d, body = %q; want %d, %q",
+				tc.debug, tc.path, rec.Code, rec.Body.String(), tc.code, tc.body)
+		}
+	}
+
+	if !errors.Is(ErrNotFound.WithInternal(dbErr), dbErr) || ErrNotFound.Internal != nil {
+		t.Error("WithInternal must wrap the error without changing the sentinel")
+	}
+}
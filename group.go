@@ -0,0 +1,98 @@
+package tong
+
+import "net/http"
+
+// Group is a set of routes sharing a path prefix and middleware.
+type Group struct {
+	prefix     string
+	host       string
+	middleware []MiddlewareFunc
+	router     *Router
+	tong       *Tong
+}
+
+// Group creates a route group with the path prefix and middleware.
+func (t *Tong) Group(prefix string, m ...MiddlewareFunc) *Group {
+	g := &Group{prefix: prefix, router: t.router, tong: t}
+	g.Use(m...)
+	return g
+}
+
+// Group creates a nested route group, its prefix and middleware
+// are appended to the ones of the parent group.
+func (g *Group) Group(prefix string, m ...MiddlewareFunc) *Group {
+	sub := &Group{prefix: g.prefix + prefix, host: g.host, router: g.router, tong: g.tong}
+	sub.Use(g.middleware...)
+	sub.Use(m...)
+	return sub
+}
+
+// Router returns the router of the group, which is the router of the host for a host group.
+func (g *Group) Router() *Router {
+	return g.router
+}
+
+// Use adds middleware to the group,
+// it only applies to the routes added after it.
+func (g *Group) Use(m ...MiddlewareFunc) {
+	g.middleware = append(g.middleware, m...)
+}
+
+func (g *Group) GET(p string, h HandlerFunc, m ...MiddlewareFunc) *RouteInfo {
+	return g.Add(http.MethodGet, p, h, m...)
+}
+
+func (g *Group) POST(p string, h HandlerFunc, m ...MiddlewareFunc) *RouteInfo {
+	return g.Add(http.MethodPost, p, h, m...)
+}
+
+func (g *Group) PUT(p string, h HandlerFunc, m ...MiddlewareFunc) *RouteInfo {
+	return g.Add(http.MethodPut, p, h, m...)
+}
+
+func (g *Group) DELETE(p string, h HandlerFunc, m ...MiddlewareFunc) *RouteInfo {
+	return g.Add(http.MethodDelete, p, h, m...)
+}
+
+func (g *Group) PATCH(p string, h HandlerFunc, m ...MiddlewareFunc) *RouteInfo {
+	return g.Add(http.MethodPatch, p, h, m...)
+}
+
+func (g *Group) HEAD(p string, h HandlerFunc, m ...MiddlewareFunc) *RouteInfo {
+	return g.Add(http.MethodHead, p, h, m...)
+}
+
+func (g *Group) OPTIONS(p string, h HandlerFunc, m ...MiddlewareFunc) *RouteInfo {
+	return g.Add(http.MethodOptions, p, h, m...)
+}
+
+func (g *Group) CONNECT(p string, h HandlerFunc, m ...MiddlewareFunc) *RouteInfo {
+	return g.Add(http.MethodConnect, p, h, m...)
+}
+
+func (g *Group) TRACE(p string, h HandlerFunc, m ...MiddlewareFunc) *RouteInfo {
+	return g.Add(http.MethodTrace, p, h, m...)
+}
+
+// Any registers a route in the group for all the standard HTTP methods.
+func (g *Group) Any(p string, h HandlerFunc, m ...MiddlewareFunc) []*RouteInfo {
+	return g.Match(methods[:], p, h, m...)
+}
+
+// Match registers a route in the group for each of the given HTTP methods.
+func (g *Group) Match(methods []string, p string, h HandlerFunc, m ...MiddlewareFunc) []*RouteInfo {
+	routes := make([]*RouteInfo, 0, len(methods))
+	for _, method := range methods {
+		routes = append(routes, g.Add(method, p, h, m...))
+	} // for>
+	return routes
+}
+
+// Add registers a route in the group, the group middleware runs
+// before the route middleware.
+func (g *Group) Add(method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) *RouteInfo {
+	m := make([]MiddlewareFunc, 0, len(g.middleware)+len(middleware))
+	m = append(m, g.middleware...)
+	m = append(m, middleware...)
+	return g.tong.add(g, method, g.prefix+path, handler, m...)
+}
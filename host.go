@@ -0,0 +1,70 @@
+package tong
+
+import "strings"
+
+// Host creates a route group served only for requests to host,
+// the routes are added to a router of the host instead of the default router.
+// A label of host like {tenant} in {tenant}.example.com matches any label
+// and is captured as a param, requests to other hosts fall back to the default router.
+func (t *Tong) Host(host string, m ...MiddlewareFunc) *Group {
+	host = strings.ToLower(host)
+	router, ok := t.hosts[host]
+	if !ok {
+		router = NewRouter()
+		t.hosts[host] = router
+		if strings.IndexByte(host, '{') >= 0 {
+			t.hostPatterns = append(t.hostPatterns, host)
+		} // if>>
+	} // if>
+	g := &Group{tong: t, host: host, router: router}
+	g.Use(m...)
+	return g
+}
+
+// hostRouter returns the router serving host and the params captured from it.
+func (t *Tong) hostRouter(host string) (*Router, []string, []string) {
+	if len(t.hosts) == 0 {
+		return t.router, nil, nil
+	} // if>
+	host = strings.ToLower(stripPort(host))
+	if router, ok := t.hosts[host]; ok {
+		return router, nil, nil
+	} // if>
+	for _, pattern := range t.hostPatterns {
+		if names, values, ok := matchHost(pattern, host); ok {
+			return t.hosts[pattern], names, values
+		} // if>>
+	} // for>
+	return t.router, nil, nil
+}
+
+// matchHost matches host against pattern label by label,
+// the labels of pattern like {name} match any label of host.
+func matchHost(pattern, host string) ([]string, []string, bool) {
+	labels := strings.Split(pattern, ".")
+	hostLabels := strings.Split(host, ".")
+	if len(labels) != len(hostLabels) {
+		return nil, nil, false
+	} // if>
+	var names, values []string
+	for i, label := range labels {
+		if len(label) > 2 && label[0] == '{' && label[len(label)-1] == '}' {
+			names = append(names, label[1:len(label)-1])
+			values = append(values, hostLabels[i])
+			continue
+		} // if>>
+		if label != hostLabels[i] {
+			return nil, nil, false
+		} // if>>
+	} // for>
+	return names, values, true
+}
+
+// stripPort removes the port from a host like example.com:8080 or [::1]:8080.
+func stripPort(host string) string {
+	i := strings.LastIndexByte(host, ':')
+	if i < 0 || strings.IndexByte(host[i:], ']') >= 0 {
+		return host
+	} // if>
+	return host[:i]
+}
@@ -0,0 +1,73 @@
+package tong
+
+import (
+	"net/http"
+	"strings"
+)
+
+// WrapHandler wraps an http.Handler into a HandlerFunc.
+func WrapHandler(h http.Handler) HandlerFunc {
+	return func(c *Context) error {
+		h.ServeHTTP(c.Response(), c.Request())
+		return nil
+	}
+}
+
+// WrapMiddleware wraps a net/http style middleware into a MiddlewareFunc,
+// the request and the response writer passed down by the middleware are
+// set to the Context before the next handler runs.
+func WrapMiddleware(m func(http.Handler) http.Handler) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) (err error) {
+			m(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				c.SetRequest(r)
+				if w != c.Response() {
+					c.SetResponse(NewResponse(w))
+				} // if>>>
+				err = next(c)
+			})).ServeHTTP(c.Response(), c.Request())
+			return err
+		}
+	}
+}
+
+// HTTPHandler converts a HandlerFunc into an http.Handler,
+// each request runs with a Context from the pool of t.
+func (t *Tong) HTTPHandler(h HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := t.acquireContext(w, r)
+		if err := h(c); err != nil {
+			t.HTTPErrorHandler(c, err)
+		} // if>>
+		t.releaseContext(c)
+	})
+}
+
+// HTTPMiddleware converts a MiddlewareFunc into a net/http style middleware,
+// each request runs with a Context from the pool of t.
+func (t *Tong) HTTPMiddleware(m MiddlewareFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return t.HTTPHandler(m(func(c *Context) error {
+			next.ServeHTTP(c.Response(), c.Request())
+			return nil
+		}))
+	}
+}
+
+// Mount serves h for prefix and all the paths under it, e.g. an http.ServeMux,
+// net/http/pprof or another *Tong. the prefix is stripped from the request path
+// before it is passed to h.
+func (t *Tong) Mount(prefix string, h http.Handler, m ...MiddlewareFunc) []*RouteInfo {
+	return t.Group("").Mount(prefix, h, m...)
+}
+
+// Mount serves h for prefix and all the paths under it in the group, see Tong.Mount.
+func (g *Group) Mount(prefix string, h http.Handler, m ...MiddlewareFunc) []*RouteInfo {
+	prefix = strings.TrimSuffix(prefix, "/")
+	handler := WrapHandler(http.StripPrefix(g.prefix+prefix, h))
+	routes := make([]*RouteInfo, 0, 2*len(methods))
+	if prefix != "" {
+		routes = append(routes, g.Any(prefix, handler, m...)...)
+	} // if>
+	return append(routes, g.Any(prefix+"/*", handler, m...)...)
+}
@@ -0,0 +1,112 @@
+package tong
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParamTypes are the named constraints of path params, e.g. /user/:id<int>.
+// a constraint which is not a name here is a regular expression the whole
+// param must match, e.g. /file/:name<[a-z0-9_-]+>.
+var ParamTypes = map[string]func(string) bool{
+	"int":   isInt,
+	"uuid":  isUUID,
+	"date":  isDate,
+	"alpha": isAlpha,
+}
+
+// paramConstraint restricts the values a path param matches.
+type paramConstraint struct {
+	expr  string
+	match func(string) bool
+}
+
+// newParamConstraint returns the constraint of expr, or nil if expr is empty.
+func newParamConstraint(expr string) (*paramConstraint, error) {
+	if expr == "" {
+		return nil, nil
+	} // if>
+	if match, ok := ParamTypes[expr]; ok {
+		return &paramConstraint{expr: expr, match: match}, nil
+	} // if>
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("tong: invalid param constraint <%s>: %v", expr, err)
+	} // if>
+	return &paramConstraint{expr: expr, match: re.MatchString}, nil
+}
+
+// checkParams returns an error if a param constraint of path is invalid, or is
+// unterminated in its segment, e.g. :p<[a-z]+/[a-z]+>, since a param matches
+// one segment only.
+func checkParams(path string) error {
+	for i := 0; i < len(path); i++ {
+		if path[i] != ':' {
+			continue
+		} // if>>
+		j := i + 1
+		for j < len(path) && path[j] != '/' {
+			j++
+		} // for>>
+		segment := path[i+1 : j]
+		if strings.IndexByte(segment, '<') >= 0 && segment[len(segment)-1] != '>' {
+			return fmt.Errorf("tong: unterminated param constraint in %s, a constraint can not contain /", path)
+		} // if>>
+		if _, expr := splitParam(segment); expr != "" {
+			if _, err := newParamConstraint(expr); err != nil {
+				return err
+			} // if>>>
+		} // if>>
+		i = j
+	} // for>
+	return nil
+}
+
+// splitParam splits a param segment like id<int> into its name and constraint.
+func splitParam(segment string) (string, string) {
+	i := strings.IndexByte(segment, '<')
+	if i < 0 || segment[len(segment)-1] != '>' {
+		return segment, ""
+	} // if>
+	return segment[:i], segment[i+1 : len(segment)-1]
+}
+
+func isInt(s string) bool {
+	_, err := strconv.ParseInt(s, 10, 64)
+	return err == nil
+}
+
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	} // if>
+	for i := 0; i < len(s); i++ {
+		switch {
+		case i == 8 || i == 13 || i == 18 || i == 23:
+			if s[i] != '-' {
+				return false
+			} // if>>>
+		case s[i] >= '0' && s[i] <= '9', s[i] >= 'a' && s[i] <= 'f', s[i] >= 'A' && s[i] <= 'F':
+		default:
+			return false
+		} // switch>>
+	} // for>
+	return true
+}
+
+func isDate(s string) bool {
+	_, err := time.Parse("2006-01-02", s)
+	return err == nil
+}
+
+func isAlpha(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !(s[i] >= 'a' && s[i] <= 'z' || s[i] >= 'A' && s[i] <= 'Z') {
+			return false
+		} // if>>
+	} // for>
+	return s != ""
+}
@@ -0,0 +1,292 @@
+package tong
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValueReader reads typed values from the query, the form, the path params
+// or the headers. the lenient readers return the default value when the key
+// is missing or invalid, the strict Parse readers return a 400 HTTPError
+// telling the client what is wrong instead.
+type ValueReader struct {
+	source string
+	values func(key string) []string
+	// err of reading the source, e.g. a body beyond BodyLimit
+	err error
+}
+
+// QueryReader returns the reader of the query string.
+func (c *Context) QueryReader() ValueReader {
+	query := c.request.URL.Query()
+	return ValueReader{source: "query parameter", values: func(key string) []string {
+		return query[key]
+	}}
+}
+
+// FormReader returns the reader of the urlencoded or multipart form,
+// the form is parsed with the memory of BodyLimit. if the body can not be
+// read, the strict readers return 413 or 400 like Bind.
+func (c *Context) FormReader() ValueReader {
+	if c.request.PostForm == nil {
+		// ParseMultipartForm hides the error of ParseForm behind ErrNotMultipart
+		err := c.request.ParseForm()
+		if err == nil {
+			if err = c.request.ParseMultipartForm(c.multipartMemory()); err == http.ErrNotMultipart {
+				err = nil
+			} // if>>>
+		} // if>>
+		if err != nil {
+			// kept for the later readers, the form is parsed only once
+			c.formErr = c.bodyError("form", err)
+		} // if>>
+	} // if>
+	form := c.request.PostForm
+	return ValueReader{source: "form field", err: c.formErr, values: func(key string) []string {
+		return form[key]
+	}}
+}
+
+// ParamReader returns the reader of the path params.
+func (c *Context) ParamReader() ValueReader {
+	return ValueReader{source: "path parameter", values: func(key string) []string {
+		if value := c.Param(key); value != "" {
+			return []string{value}
+		} // if>>
+		return nil
+	}}
+}
+
+// HeaderReader returns the reader of the request headers.
+func (c *Context) HeaderReader() ValueReader {
+	header := c.request.Header
+	return ValueReader{source: "header", values: func(key string) []string {
+		return header[textproto.CanonicalMIMEHeaderKey(key)]
+	}}
+}
+
+// Has reports whether the key has a non-empty value.
+func (r ValueReader) Has(key string) bool {
+	_, err := r.ParseString(key)
+	return err == nil
+}
+
+func (r ValueReader) missing(key string) error {
+	return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("missing %s %s", r.source, key))
+}
+
+func (r ValueReader) invalid(key, kind string, err error) error {
+	return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid %s %s, %s expected", r.source, key, kind)).
+		WithInternal(fmt.Errorf("tong: read %s %s: %v", r.source, key, err))
+}
+
+// $--- strict readers ---
+
+// ParseString returns the first value of the key, or an error if it is empty.
+func (r ValueReader) ParseString(key string) (string, error) {
+	if r.err != nil {
+		return "", r.err
+	} // if>
+	values := r.values(key)
+	if len(values) == 0 || values[0] == "" {
+		return "", r.missing(key)
+	} // if>
+	return values[0], nil
+}
+
+// ParseStrings returns all the values of a repeated key, e.g. ?tag=a&tag=b.
+func (r ValueReader) ParseStrings(key string) ([]string, error) {
+	if r.err != nil {
+		return nil, r.err
+	} // if>
+	values := r.values(key)
+	if len(values) == 0 {
+		return nil, r.missing(key)
+	} // if>
+	return values, nil
+}
+
+// ParseInts returns all the values of a repeated key as ints.
+func (r ValueReader) ParseInts(key string) ([]int, error) {
+	values, err := r.ParseStrings(key)
+	if err != nil {
+		return nil, err
+	} // if>
+	ret := make([]int, len(values))
+	for i, value := range values {
+		if ret[i], err = strconv.Atoi(value); err != nil {
+			return nil, r.invalid(key, "int", err)
+		} // if>>
+	} // for>
+	return ret, nil
+}
+
+func (r ValueReader) ParseInt(key string) (int, error) {
+	value, err := r.ParseString(key)
+	if err != nil {
+		return 0, err
+	} // if>
+	ret, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, r.invalid(key, "int", err)
+	} // if>
+	return ret, nil
+}
+
+func (r ValueReader) ParseInt64(key string) (int64, error) {
+	value, err := r.ParseString(key)
+	if err != nil {
+		return 0, err
+	} // if>
+	ret, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, r.invalid(key, "int64", err)
+	} // if>
+	return ret, nil
+}
+
+func (r ValueReader) ParseUint(key string) (uint, error) {
+	value, err := r.ParseString(key)
+	if err != nil {
+		return 0, err
+	} // if>
+	ret, err := strconv.ParseUint(value, 10, 0)
+	if err != nil {
+		return 0, r.invalid(key, "uint", err)
+	} // if>
+	return uint(ret), nil
+}
+
+func (r ValueReader) ParseFloat(key string) (float64, error) {
+	value, err := r.ParseString(key)
+	if err != nil {
+		return 0, err
+	} // if>
+	ret, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, r.invalid(key, "float", err)
+	} // if>
+	return ret, nil
+}
+
+// ParseBool accepts on and off of the html checkbox besides strconv.ParseBool.
+func (r ValueReader) ParseBool(key string) (bool, error) {
+	value, err := r.ParseString(key)
+	if err != nil {
+		return false, err
+	} // if>
+	switch strings.ToLower(value) {
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	} // switch>
+	ret, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, r.invalid(key, "bool", err)
+	} // if>
+	return ret, nil
+}
+
+// ParseDuration reads the value like 1h30m by time.ParseDuration.
+func (r ValueReader) ParseDuration(key string) (time.Duration, error) {
+	value, err := r.ParseString(key)
+	if err != nil {
+		return 0, err
+	} // if>
+	ret, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, r.invalid(key, "duration", err)
+	} // if>
+	return ret, nil
+}
+
+// ParseTime reads the value by the layout of time.Parse, e.g. time.RFC3339.
+func (r ValueReader) ParseTime(key, layout string) (time.Time, error) {
+	value, err := r.ParseString(key)
+	if err != nil {
+		return time.Time{}, err
+	} // if>
+	ret, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}, r.invalid(key, "time of "+layout, err)
+	} // if>
+	return ret, nil
+}
+
+// $--- lenient readers ---
+
+func (r ValueReader) String(key string, defaultValue string) string {
+	if ret, err := r.ParseString(key); err == nil {
+		return ret
+	} // if>
+	return defaultValue
+}
+
+// Strings returns all the values of a repeated key, or nil.
+func (r ValueReader) Strings(key string) []string {
+	ret, _ := r.ParseStrings(key)
+	return ret
+}
+
+// Ints returns all the values of a repeated key as ints,
+// or defaultValue if any of them is not an int.
+func (r ValueReader) Ints(key string, defaultValue []int) []int {
+	if ret, err := r.ParseInts(key); err == nil {
+		return ret
+	} // if>
+	return defaultValue
+}
+
+func (r ValueReader) Int(key string, defaultValue int) int {
+	if ret, err := r.ParseInt(key); err == nil {
+		return ret
+	} // if>
+	return defaultValue
+}
+
+func (r ValueReader) Int64(key string, defaultValue int64) int64 {
+	if ret, err := r.ParseInt64(key); err == nil {
+		return ret
+	} // if>
+	return defaultValue
+}
+
+func (r ValueReader) Uint(key string, defaultValue uint) uint {
+	if ret, err := r.ParseUint(key); err == nil {
+		return ret
+	} // if>
+	return defaultValue
+}
+
+func (r ValueReader) Float(key string, defaultValue float64) float64 {
+	if ret, err := r.ParseFloat(key); err == nil {
+		return ret
+	} // if>
+	return defaultValue
+}
+
+func (r ValueReader) Bool(key string, defaultValue bool) bool {
+	if ret, err := r.ParseBool(key); err == nil {
+		return ret
+	} // if>
+	return defaultValue
+}
+
+func (r ValueReader) Duration(key string, defaultValue time.Duration) time.Duration {
+	if ret, err := r.ParseDuration(key); err == nil {
+		return ret
+	} // if>
+	return defaultValue
+}
+
+func (r ValueReader) Time(key, layout string, defaultValue time.Time) time.Time {
+	if ret, err := r.ParseTime(key, layout); err == nil {
+		return ret
+	} // if>
+	return defaultValue
+}
@@ -0,0 +1,89 @@
+package tong
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValueReaders(t *testing.T) {
+	tong := newTestTong()
+	tong.POST("/items/:id", func(c *Context) error {
+		q, f, p, h := c.QueryReader(), c.FormReader(), c.ParamReader(), c.HeaderReader()
+		if got := q.Strings("tag"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+			t.Errorf("unexpected tags %v", got)
+		} // if>>
+		if got := q.Ints("n", nil); !reflect.DeepEqual(got, []int{1, 2}) {
+			t.Errorf("unexpected ints %v", got)
+		} // if>>
+		if q.Int64("big", 0) != 1<<40 || q.Uint("count", 9) != 9 || !q.Bool("debug", false) {
+			t.Error("unexpected query values")
+		} // if>>
+		if q.Duration("wait", 0) != 90*time.Minute {
+			t.Error("unexpected duration")
+		} // if>>
+		if day := q.Time("day", "2006-01-02", time.Time{}); day.Month() != time.March || day.Day() != 4 {
+			t.Errorf("unexpected time %v", day)
+		} // if>>
+		if f.String("name", "") != "tong" || !f.Bool("agree", false) || p.Int("id", 0) != 7 || h.Int("X-Page", 0) != 3 {
+			t.Error("unexpected form, param or header values")
+		} // if>>
+		if c.PostString("name", "") != "tong" || c.QueryInt("debug", 5) != 5 {
+			t.Error("unexpected values of the old readers")
+		} // if>>
+
+		// the strict readers tell what is wrong
+		if _, err := q.ParseUint("count"); err == nil || err.(*HTTPError).Message != "invalid query parameter count, uint expected" {
+			t.Errorf("unexpected error %v", err)
+		} // if>>
+		if _, err := f.ParseInt("missing"); err == nil || err.(*HTTPError).Message != "missing form field missing" {
+			t.Errorf("unexpected error %v", err)
+		} // if>>
+		_, err := q.ParseTime("when", time.RFC3339)
+		return err
+	})
+
+	body := strings.NewReader("name=tong&agree=on")
+	req := httptest.NewRequest(http.MethodPost, "/items/7?tag=a&tag=b&n=1&n=2&big=1099511627776&count=-1&debug=true&wait=1h30m&day=2020-03-04&when=yesterday", body)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Page", "3")
+	rec := httptest.NewRecorder()
+	tong.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid query parameter when") {
+		t.Fatalf("expected 400 of the strict reader, got %d %s", rec.Code, rec.Body.String())
+	} // if>
+}
+
+func TestFormReaderBodyError(t *testing.T) {
+	tong := newTestTong()
+	handler := func(c *Context) error {
+		if c.PostString("name", "default") != "default" {
+			t.Error("the old reader did not return the default value")
+		} // if>>
+		_, err := c.FormReader().ParseString("name")
+		return err
+	}
+	tong.POST("/limited", handler, BodyLimit(16, 0))
+	tong.POST("/form", handler)
+
+	for _, tc := range []struct {
+		path string
+		body string
+		code int
+	}{
+		{"/limited", "name=" + strings.Repeat("a", 100), http.StatusRequestEntityTooLarge},
+		{"/form", "name=%zz", http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+		req.ContentLength = -1
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		tong.ServeHTTP(rec, req)
+		if rec.Code != tc.code || strings.Contains(rec.Body.String(), "missing") {
+			t.Errorf("%s: expected %d, got %d %s", tc.path, tc.code, rec.Code, rec.Body.String())
+		} // if>>
+	} // for>
+}
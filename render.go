@@ -0,0 +1,160 @@
+package tong
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ming3000/tong/common"
+)
+
+// Renderer encodes a value to the response body.
+type Renderer interface {
+	Render(w io.Writer, v interface{}) error
+}
+
+// RendererFunc is an adapter to use a function as a Renderer.
+type RendererFunc func(w io.Writer, v interface{}) error
+
+// Render implements the Renderer interface.
+func (f RendererFunc) Render(w io.Writer, v interface{}) error {
+	return f(w, v)
+}
+
+// JSONRenderer encodes values as json.
+var JSONRenderer = RendererFunc(func(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+})
+
+// XMLRenderer encodes values as xml.
+var XMLRenderer = RendererFunc(func(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	} // if>
+	return xml.NewEncoder(w).Encode(v)
+})
+
+// TextRenderer writes values as text, like fmt.Print does.
+var TextRenderer = RendererFunc(func(w io.Writer, v interface{}) error {
+	_, err := fmt.Fprint(w, v)
+	return err
+})
+
+// renderer is a Renderer registered for a media type.
+type renderer struct {
+	mediaType   string
+	contentType string
+	Renderer
+}
+
+// RegisterRenderer registers r for the media type, e.g. application/msgpack,
+// replacing the one registered before. the renderers registered first are
+// preferred when the client accepts several media types equally.
+// json, xml and plain text are registered by default.
+func (t *Tong) RegisterRenderer(mediaType string, r Renderer) {
+	contentType := mediaType
+	if strings.HasPrefix(mediaType, "text/") || mediaType == common.MIMEApplicationJSON ||
+		mediaType == common.MIMEApplicationXML {
+		contentType += "; charset=UTF-8"
+	} // if>
+	for _, rd := range t.renderers {
+		if rd.mediaType == mediaType {
+			rd.contentType, rd.Renderer = contentType, r
+			return
+		} // if>>
+	} // for>
+	t.renderers = append(t.renderers, &renderer{mediaType: mediaType, contentType: contentType, Renderer: r})
+}
+
+// Render sends the value encoded by the renderer of the media type
+// the client accepts best, see Context.Negotiate.
+// it returns ErrNotAcceptable if the client accepts none of them.
+func (c *Context) Render(code int, value interface{}) error {
+	offers := make([]string, 0, len(c.tong.renderers))
+	for _, rd := range c.tong.renderers {
+		offers = append(offers, rd.mediaType)
+	} // for>
+	c.response.Header().Add(common.HeaderVary, common.HeaderAccept)
+	mediaType := c.Negotiate(offers...)
+	for _, rd := range c.tong.renderers {
+		if rd.mediaType == mediaType {
+			c.WriteContentType(rd.contentType)
+			c.response.WriteHeader(code)
+			return rd.Render(c.response, value)
+		} // if>>
+	} // for>
+	return ErrNotAcceptable
+}
+
+// Negotiate returns the offered media type the Accept header of the request
+// prefers by its q-values, the first offer if there is no Accept header,
+// or "" if none is acceptable. the earlier offers win the ties.
+func (c *Context) Negotiate(offers ...string) string {
+	accept := c.request.Header.Get(common.HeaderAccept)
+	if accept == "" {
+		if len(offers) == 0 {
+			return ""
+		} // if>>
+		return offers[0]
+	} // if>
+
+	ranges := parseAccept(accept)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		if q := acceptQuality(ranges, offer); q > bestQ {
+			best, bestQ = offer, q
+		} // if>>
+	} // for>
+	return best
+}
+
+// acceptRange is a media range of the Accept header.
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+func parseAccept(accept string) []acceptRange {
+	ranges := make([]acceptRange, 0, strings.Count(accept, ",")+1)
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		ar := acceptRange{mediaType: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+		for _, p := range params[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				if q, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					ar.q = q
+				} // if>>>>
+			} // if>>>
+		} // for>>
+		if ar.mediaType != "" {
+			ranges = append(ranges, ar)
+		} // if>>
+	} // for>
+	return ranges
+}
+
+// acceptQuality returns the q-value of the most specific range matching the media type.
+func acceptQuality(ranges []acceptRange, mediaType string) float64 {
+	mediaType = strings.ToLower(mediaType)
+	slash := strings.IndexByte(mediaType, '/')
+	q, specificity := 0.0, 0
+	for _, ar := range ranges {
+		s := 0
+		switch {
+		case ar.mediaType == mediaType:
+			s = 3
+		case slash > 0 && ar.mediaType == mediaType[:slash]+"/*":
+			s = 2
+		case ar.mediaType == "*/*":
+			s = 1
+		} // switch>>
+		if s > specificity {
+			q, specificity = ar.q, s
+		} // if>>
+	} // for>
+	return q
+}
@@ -0,0 +1,55 @@
+package tong
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type renderItem struct {
+	Name  string `json:"name" xml:"name"`
+	Count int    `json:"count" xml:"count"`
+}
+
+func (r renderItem) String() string {
+	return fmt.Sprintf("%s x%d", r.Name, r.Count)
+}
+
+func TestRender(t *testing.T) {
+	tong := newTestTong()
+	tong.RegisterRenderer("text/csv", RendererFunc(func(w io.Writer, v interface{}) error {
+		item := v.(renderItem)
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{item.Name, fmt.Sprint(item.Count)})
+		cw.Flush()
+		return cw.Error()
+	}))
+	tong.GET("/item", func(c *Context) error {
+		return c.Render(http.StatusOK, renderItem{Name: "tong", Count: 2})
+	})
+
+	for _, tc := range []struct {
+		accept, contentType, body string
+		code                      int
+	}{
+		{"", "application/json; charset=UTF-8", `{"name":"tong","count":2}` + "\n", http.StatusOK},
+		{"application/xml;q=0.9, application/json;q=0.5", "application/xml; charset=UTF-8",
+			`<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<renderItem><name>tong</name><count>2</count></renderItem>`, http.StatusOK},
+		{"text/*", "text/plain; charset=UTF-8", "tong x2", http.StatusOK},
+		{"text/csv, */*;q=0.1", "text/csv; charset=UTF-8", "tong,2\n", http.StatusOK},
+		{"application/json;q=0, */*", "text/plain; charset=UTF-8", "tong x2", http.StatusOK},
+		{"image/png", "text/plain; charset=UTF-8", "Not Acceptable", http.StatusNotAcceptable},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/item", nil)
+		req.Header.Set("Accept", tc.accept)
+		rec := httptest.NewRecorder()
+		tong.ServeHTTP(rec, req)
+		if rec.Code != tc.code || rec.Header().Get("Content-Type") != tc.contentType || rec.Body.String() != tc.body {
+			t.Errorf("Accept %q: code = %d, Content-Type = %q, body = %q",
+				tc.accept, rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+		}
+	}
+}
@@ -14,6 +14,7 @@ type Response struct {
 	Status          int
 	Size            int
 	IfHeaderBeenSet bool
+	discardBody     bool
 }
 
 // NewResponse create a new instance of Response
@@ -27,6 +28,7 @@ func (r *Response) Reset(w http.ResponseWriter) {
 	r.Status = http.StatusOK
 	r.Size = 0
 	r.IfHeaderBeenSet = false
+	r.discardBody = false
 }
 
 // Header returns the http.header map of the writer
@@ -53,6 +55,12 @@ func (r *Response) Write(data []byte) (int, error) {
 		r.WriteHeader(r.Status)
 	} // if>
 
+	// the body of a HEAD response served by a GET handler is counted but not sent
+	if r.discardBody {
+		r.Size += len(data)
+		return len(data), nil
+	} // if>
+
 	n, err := r.Writer.Write(data)
 	r.Size += n
 	return n, err
@@ -68,7 +76,13 @@ func (r *Response) Flush() {
 // https://golang.org/pkg/net/http/#Hijacker
 func (r *Response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	if hijacker, ok := r.Writer.(http.Hijacker); ok {
-		return hijacker.Hijack()
+		conn, brw, err := hijacker.Hijack()
+		if err == nil {
+			// nothing can be written by the response any more
+			r.Status = http.StatusSwitchingProtocols
+			r.IfHeaderBeenSet = true
+		} // if>>
+		return conn, brw, err
 	} else {
 		return nil, nil, errors.New("reflect Hijacker error")
 	} // else>
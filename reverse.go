@@ -0,0 +1,97 @@
+package tong
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Reverse builds the URL path of the route named name.
+// params are name/value pairs, a pair whose name is a path parameter
+// of the route fills that parameter, the others are appended as the query string,
+// e.g. Reverse("user.show", "id", 42, "tab", "posts") returns /user/42?tab=posts
+// for a route /user/:id.
+func (t *Tong) Reverse(name string, params ...interface{}) (string, error) {
+	var route *RouteInfo
+	for _, r := range t.Routes() {
+		if r.Name == name {
+			route = r
+			break
+		} // if>>
+	} // for>
+	if route == nil {
+		return "", fmt.Errorf("tong: no route named %q", name)
+	} // if>
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("tong: odd number of params for route %q", name)
+	} // if>
+
+	values := make(map[string]string, len(params)/2)
+	keys := make([]string, 0, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		key, ok := params[i].(string)
+		if !ok {
+			return "", fmt.Errorf("tong: param name %v of route %q is not a string", params[i], name)
+		} // if>>
+		if _, exists := values[key]; !exists {
+			keys = append(keys, key)
+		} // if>>
+		values[key] = fmt.Sprint(params[i+1])
+	} // for>
+
+	path := fixPath(route.Path)
+	used := make(map[string]bool, len(keys))
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case ':':
+			j := i + 1
+			for j < len(path) && path[j] != '/' {
+				j++
+			} // for>>>
+			pname, _ := splitParam(path[i+1 : j])
+			value, ok := values[pname]
+			if !ok {
+				return "", fmt.Errorf("tong: missing param %q for route %q", pname, name)
+			} // if>>>
+			used[pname] = true
+			b.WriteString(url.PathEscape(value))
+			i = j - 1
+		case '*':
+			pname := path[i+1:]
+			if pname == "" {
+				pname = "*"
+			} // if>>>
+			value, ok := values[pname]
+			if !ok {
+				return "", fmt.Errorf("tong: missing param %q for route %q", pname, name)
+			} // if>>>
+			used[pname] = true
+			segments := strings.Split(value, "/")
+			for k := range segments {
+				segments[k] = url.PathEscape(segments[k])
+			} // for>>>
+			b.WriteString(strings.Join(segments, "/"))
+			i = len(path)
+		default:
+			b.WriteByte(path[i])
+		} // switch>>
+	} // for>
+
+	query := url.Values{}
+	for _, key := range keys {
+		if !used[key] {
+			query.Set(key, values[key])
+		} // if>>
+	} // for>
+	if len(query) > 0 {
+		b.WriteByte('?')
+		b.WriteString(query.Encode())
+	} // if>
+	return b.String(), nil
+}
+
+// URLFor builds the URL path of the route named name, see Tong.Reverse.
+func (c *Context) URLFor(name string, params ...interface{}) (string, error) {
+	return c.tong.Reverse(name, params...)
+}
@@ -1,100 +1,606 @@
 package tong
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/ming3000/tong/common"
+)
 
 // RouteInfo is detail info of request.
 type RouteInfo struct {
-	Method string
-	Path   string
-	Name   string
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	Name       string   `json:"name"`
+	Group      string   `json:"group"`
+	Host       string   `json:"host"`
+	Middleware []string `json:"middleware"`
+	// Err is why the route was not registered, e.g. a *RouteConflictError.
+	Err error `json:"-"`
 }
 
+// SetName sets the name of the route, which is used by Tong.Reverse.
+// the default name is the function name of the handler.
+func (r *RouteInfo) SetName(name string) *RouteInfo {
+	r.Name = name
+	return r
+}
+
+// PathPolicy decides how the router treats a request path
+// which matches a route only after it is fixed.
+type PathPolicy uint8
+
+const (
+	// PathStrict does not fix the path, it must match a route as it is.
+	PathStrict PathPolicy = iota
+	// PathRedirect redirects to the fixed path, with 301 for GET and HEAD
+	// and 308 for the other methods so they are preserved.
+	PathRedirect
+	// PathMatch serves the fixed path as if it was requested.
+	PathMatch
+)
+
 // Router is for request matching.
 type Router struct {
-	root   *treeNode
-	routes map[string]*RouteInfo
+	root      *treeNode
+	routes    map[string]*RouteInfo
+	maxParams int
+	// TrailingSlash fixes a path by adding or removing the trailing slash.
+	TrailingSlash PathPolicy
+	// CleanPath fixes a path like path.Clean does before the lookup,
+	// e.g. //users/../admin is fixed to /admin.
+	CleanPath PathPolicy
+	// CaseInsensitive fixes a path by looking it up case-insensitively.
+	CaseInsensitive PathPolicy
 }
 
 // NewRouter returns a new Router instance.
 func NewRouter() *Router {
 	return &Router{
-		root:   newTreeNode(),
+		root:   newTreeNode(""),
 		routes: map[string]*RouteInfo{},
 	}
 }
 
 // Add registers a route for method and path with matching handler.
-func (r *Router) Add(method, path string, h HandlerFunc) {
-	r.root.insert(method, fixPath(path), h)
+// A path segment starting with ':' is a named parameter which matches
+// one segment, e.g. /user/:id, and a trailing '*' segment is a wildcard
+// which matches the rest of the path, e.g. /static/*filepath.
+// A param may have a constraint, either a name of ParamTypes or a regular
+// expression, e.g. /user/:id<int> or /file/:name<[a-z0-9_-]+>, a request not
+// satisfying it falls through to the other routes. A constraint can not
+// contain '/', since a param matches one segment.
+// Any valid HTTP method token is accepted, including custom ones like PROPFIND.
+// A *RouteConflictError is returned if the route is already registered, or
+// its params are named differently from a registered route at the same segment.
+func (r *Router) Add(method, path string, h HandlerFunc) error {
+	if !validMethod(method) {
+		return fmt.Errorf("tong: invalid http method %q", method)
+	} // if>
+	if h == nil {
+		return fmt.Errorf("tong: nil handler for %s %s", method, path)
+	} // if>
+	if err := checkParams(path); err != nil {
+		return err
+	} // if>
+	if reason := r.root.conflict(method, fixPath(path)); reason != "" {
+		return &RouteConflictError{Method: method, Path: path, Reason: reason}
+	} // if>
+	n, err := r.root.insert(method, fixPath(path), h)
+	if err != nil {
+		return err
+	} // if>
+	if n > r.maxParams {
+		r.maxParams = n
+	} // if>
+	return nil
+}
+
+// RouteConflictError describes a route conflicting with the registered routes.
+type RouteConflictError struct {
+	Method string
+	Path   string
+	Reason string
+}
+
+func (e *RouteConflictError) Error() string {
+	return fmt.Sprintf("tong: route %s %s conflicts with the registered routes: %s", e.Method, e.Path, e.Reason)
 }
 
 // Find a handler registered for method and path.
+// The matched route pattern and the captured parameters are stored in ctx.
+// If no route matches the path, the handler is the NotFoundHandler;
+// if the path matches but the method does not, the handler is the
+// MethodNotAllowedHandler and the Allow header lists the registered methods.
 func (r *Router) Find(method, path string, ctx *Context) {
-	h := r.root.search(method, fixPath(path))
-	ctx.handler = h
+	r.find(method, path, false, ctx)
+}
+
+// find is Find for a path which keeps the escapes %2F and %25 if raw,
+// the captured params are unescaped then.
+func (r *Router) find(method, path string, raw bool, ctx *Context) {
+	// the params slice of a pooled context is reused, so lookups do not allocate
+	if cap(ctx.pvalues) < r.maxParams {
+		ctx.pvalues = make([]string, 0, r.maxParams)
+	} // if>
+	path = fixPath(path)
+	redirect := false
+	if r.CleanPath != PathStrict {
+		if cleaned := cleanPath(path); cleaned != path {
+			path = cleaned
+			redirect = r.CleanPath == PathRedirect
+		} // if>>
+	} // if>
+
+	n, values := r.root.search(path, ctx.pvalues[:0])
+	if n == nil && r.TrailingSlash != PathStrict && path != "/" {
+		alt := toggleTrailingSlash(path)
+		if n, values = r.root.search(alt, ctx.pvalues[:0]); n != nil {
+			path = alt
+			redirect = redirect || r.TrailingSlash == PathRedirect
+		} // if>>
+	} // if>
+	if n == nil && r.CaseInsensitive != PathStrict {
+		if n, values = r.root.searchFold(path, ctx.pvalues[:0]); n != nil {
+			path = fillPath(n.path, values)
+			redirect = redirect || r.CaseInsensitive == PathRedirect
+		} // if>>
+	} // if>
+	if n == nil {
+		ctx.handler = notFoundHandler(ctx)
+		return
+	} // if>
+	if redirect {
+		ctx.handler = redirectHandler(path)
+		return
+	} // if>
+
+	if raw {
+		for i, v := range values {
+			if strings.IndexByte(v, '%') < 0 {
+				continue
+			} // if>>>
+			if unescaped, err := url.PathUnescape(v); err == nil {
+				values[i] = unescaped
+			} // if>>>
+		} // for>>
+	} // if>
+	ctx.path = n.path
+	ctx.pnames = n.pnames
+	ctx.pvalues = values
+	ctx.handler = n.findHandler(method)
+	if ctx.handler != nil {
+		return
+	} // if>
+
+	allow := n.allowHeader
+	if autoHeadOptions(ctx) {
+		allow = n.autoAllowHeader
+		switch {
+		case method == http.MethodHead && n.methodHandler.get != nil:
+			// serve HEAD by the GET handler without writing the body
+			ctx.response.discardBody = true
+			ctx.handler = n.methodHandler.get
+			return
+		case method == http.MethodOptions:
+			ctx.response.Header().Set(common.HeaderAllow, allow)
+			ctx.handler = optionsHandler
+			return
+		} // switch>>
+	} // if>
+	ctx.response.Header().Set(common.HeaderAllow, allow)
+	ctx.handler = methodNotAllowedHandler(ctx)
+}
+
+// redirectHandler redirects to the fixed path keeping the query string.
+func redirectHandler(path string) HandlerFunc {
+	return func(c *Context) error {
+		code := http.StatusPermanentRedirect
+		if c.request.Method == http.MethodGet || c.request.Method == http.MethodHead {
+			code = http.StatusMovedPermanently
+		} // if>>
+		if c.request.URL.RawQuery != "" {
+			path += "?" + c.request.URL.RawQuery
+		} // if>>
+		return c.Redirect(code, path)
+	}
+}
+
+// autoHeadOptions reports whether HEAD and OPTIONS are answered automatically.
+func autoHeadOptions(ctx *Context) bool {
+	return ctx.tong == nil || !ctx.tong.DisableAutoHeadOptions
+}
+
+// optionsHandler answers an OPTIONS request with the Allow header set by the router.
+func optionsHandler(c *Context) error {
+	c.response.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// notFoundHandler returns the NotFoundHandler of the Tong instance serving ctx.
+func notFoundHandler(ctx *Context) HandlerFunc {
+	if ctx.tong != nil && ctx.tong.NotFoundHandler != nil {
+		return ctx.tong.NotFoundHandler
+	} // if>
+	return NotFoundHandler
+}
+
+// methodNotAllowedHandler returns the MethodNotAllowedHandler of the Tong instance serving ctx.
+func methodNotAllowedHandler(ctx *Context) HandlerFunc {
+	if ctx.tong != nil && ctx.tong.MethodNotAllowedHandler != nil {
+		return ctx.tong.MethodNotAllowedHandler
+	} // if>
+	return MethodNotAllowedHandler
 }
 
 type methodHandler struct {
-	get  HandlerFunc
-	post HandlerFunc
+	connect HandlerFunc
+	delete  HandlerFunc
+	get     HandlerFunc
+	head    HandlerFunc
+	options HandlerFunc
+	patch   HandlerFunc
+	post    HandlerFunc
+	put     HandlerFunc
+	trace   HandlerFunc
+	custom  map[string]HandlerFunc
 }
 
+// treeNode is a node of the radix tree, the static children are compressed
+// edges labeled by prefix, the param and wildcard children match a path
+// segment and the rest of the path respectively.
 type treeNode struct {
-	next          [128]*treeNode
-	methodHandler *methodHandler
+	prefix          string
+	indices         string
+	children        []*treeNode
+	pname           string
+	constraint      *paramConstraint
+	paramChildren   []*treeNode
+	anyChild        *treeNode
+	path            string
+	pnames          []string
+	allowHeader     string
+	autoAllowHeader string
+	methodHandler   *methodHandler
 }
 
 /** Initialize your data structure here. */
-func newTreeNode() *treeNode {
-	return &treeNode{next: [128]*treeNode{}, methodHandler: new(methodHandler)}
+func newTreeNode(prefix string) *treeNode {
+	return &treeNode{prefix: prefix, methodHandler: new(methodHandler)}
 }
 
-/** inserts a path & handler into the trie. */
-func (t *treeNode) insert(method, path string, hand HandlerFunc) {
+/** inserts a path & handler into the tree. returns the number of params of the path. */
+func (t *treeNode) insert(method, path string, hand HandlerFunc) (int, error) {
 	cur := t
-	for _, v := range path {
-		if cur.next[v] == nil {
-			cur.next[v] = newTreeNode()
+	pnames := make([]string, 0)
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case ':':
+			j := i + 1
+			for j < len(path) && path[j] != '/' {
+				j++
+			} // for>>>
+			name, expr := splitParam(path[i+1 : j])
+			pnames = append(pnames, name)
+			child := cur.findParamChild(expr)
+			if child == nil {
+				constraint, err := newParamConstraint(expr)
+				if err != nil {
+					return 0, err
+				} // if>>>>
+				child = newTreeNode("")
+				child.pname = name
+				child.constraint = constraint
+				cur.addParamChild(child)
+			} // if>>>
+			cur = child
+			i = j
+		case '*':
+			name := path[i+1:]
+			if name == "" {
+				name = "*"
+			} // if>>>
+			pnames = append(pnames, name)
+			if cur.anyChild == nil {
+				cur.anyChild = newTreeNode("")
+				cur.anyChild.pname = name
+			} // if>>>
+			cur = cur.anyChild
+			i = len(path)
+		default:
+			j := i + 1
+			for j < len(path) && path[j] != ':' && path[j] != '*' {
+				j++
+			} // for>>>
+			cur = cur.insertStatic(path[i:j])
+			i = j
+		} // switch>>
+	} // for>
+	cur.path = path
+	cur.pnames = pnames
+	cur.addHandler(method, hand)
+	return len(pnames), nil
+}
+
+// findParamChild returns the param child with the constraint expr, or nil if there is none.
+func (t *treeNode) findParamChild(expr string) *treeNode {
+	for _, child := range t.paramChildren {
+		if child.constraint == nil && expr == "" || child.constraint != nil && child.constraint.expr == expr {
+			return child
 		} // if>>
-		cur = cur.next[v]
 	} // for>
-	if cur != nil {
-		cur.addHandler(method, hand)
+	return nil
+}
+
+// addParamChild adds a param child, the constrained children are kept
+// before the unconstrained one so they are tried first.
+func (t *treeNode) addParamChild(child *treeNode) {
+	t.paramChildren = append(t.paramChildren, child)
+	if child.constraint != nil {
+		for i := len(t.paramChildren) - 1; i > 0 && t.paramChildren[i-1].constraint == nil; i-- {
+			t.paramChildren[i-1], t.paramChildren[i] = t.paramChildren[i], t.paramChildren[i-1]
+		} // for>>
 	} // if>
 }
 
-/** returns handler if the path is in the trie. */
-func (t *treeNode) search(method, path string) HandlerFunc {
+/** returns the node at the end of the static edges matching s. */
+// edges are split or added as needed.
+func (t *treeNode) insertStatic(s string) *treeNode {
+	cur := t
+	for {
+		i := strings.IndexByte(cur.indices, s[0])
+		if i < 0 {
+			n := newTreeNode(s)
+			cur.indices += s[:1]
+			cur.children = append(cur.children, n)
+			return n
+		} // if>>
+
+		child := cur.children[i]
+		l := commonPrefixLen(s, child.prefix)
+		if l < len(child.prefix) {
+			// split the edge at the end of the common prefix
+			split := newTreeNode(child.prefix[:l])
+			child.prefix = child.prefix[l:]
+			split.indices = child.prefix[:1]
+			split.children = []*treeNode{child}
+			cur.children[i] = split
+			child = split
+		} // if>>
+		if l == len(s) {
+			return child
+		} // if>>
+		s = s[l:]
+		cur = child
+	} // for>
+}
+
+/** returns the node at the end of the static edges matching s, or nil if there is none. */
+func (t *treeNode) findStatic(s string) *treeNode {
 	cur := t
-	for _, v := range path {
-		if cur.next[v] == nil {
-			return NotFoundHandler
+	for s != "" {
+		i := strings.IndexByte(cur.indices, s[0])
+		if i < 0 || !strings.HasPrefix(s, cur.children[i].prefix) {
+			return nil
 		} // if>>
-		cur = cur.next[v]
+		s = s[len(cur.children[i].prefix):]
+		cur = cur.children[i]
 	} // for>
-	if cur != nil {
-		return cur.findHandler(method)
-	} else {
-		return NotFoundHandler
-	} // else>
+	return cur
+}
+
+/** returns why the route would conflict with the tree, or "" if it would not. */
+func (t *treeNode) conflict(method, path string) string {
+	cur := t
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case ':':
+			j := i + 1
+			for j < len(path) && path[j] != '/' {
+				j++
+			} // for>>>
+			name, expr := splitParam(path[i+1 : j])
+			child := cur.findParamChild(expr)
+			if child == nil {
+				return ""
+			} // if>>>
+			if name != child.pname {
+				return fmt.Sprintf("param :%s is named :%s in another route", name, child.pname)
+			} // if>>>
+			cur = child
+			i = j
+		case '*':
+			name := path[i+1:]
+			if name == "" {
+				name = "*"
+			} // if>>>
+			if cur.anyChild == nil {
+				return ""
+			} // if>>>
+			if name != cur.anyChild.pname {
+				return fmt.Sprintf("wildcard *%s is named *%s in another route", name, cur.anyChild.pname)
+			} // if>>>
+			cur = cur.anyChild
+			i = len(path)
+		default:
+			j := i + 1
+			for j < len(path) && path[j] != ':' && path[j] != '*' {
+				j++
+			} // for>>>
+			if cur = cur.findStatic(path[i:j]); cur == nil {
+				return ""
+			} // if>>>
+			i = j
+		} // switch>>
+	} // for>
+	if cur.findHandler(method) != nil {
+		return "it is already registered"
+	} // if>
+	return ""
+}
+
+/** returns the node matching the path case-insensitively, or nil if there is none. */
+func (t *treeNode) searchFold(path string, values []string) (*treeNode, []string) {
+	if path == "" {
+		return t.search(path, values)
+	} // if>
+
+	for _, child := range t.children {
+		l := len(child.prefix)
+		if len(path) >= l && strings.EqualFold(path[:l], child.prefix) {
+			if n, v := child.searchFold(path[l:], values); n != nil {
+				return n, v
+			} // if>>>
+		} // if>>
+	} // for>
+
+	if len(t.paramChildren) > 0 {
+		i := strings.IndexByte(path, '/')
+		if i < 0 {
+			i = len(path)
+		} // if>>
+		for _, child := range t.paramChildren {
+			if i == 0 || child.constraint != nil && !child.constraint.match(path[:i]) {
+				continue
+			} // if>>>
+			if n, v := child.searchFold(path[i:], append(values, path[:i])); n != nil {
+				return n, v
+			} // if>>>
+		} // for>>
+	} // if>
+
+	if t.anyChild != nil && t.anyChild.path != "" {
+		return t.anyChild, append(values, path)
+	} // if>
+	return nil, values
+}
+
+// commonPrefixLen returns the length of the longest common prefix of a and b.
+func commonPrefixLen(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	} // for>
+	return i
+}
+
+/** returns the node matching the path, or nil if there is none. */
+// static edges are tried before params, and params before wildcards.
+func (t *treeNode) search(path string, values []string) (*treeNode, []string) {
+	if path == "" {
+		if t.path != "" {
+			return t, values
+		} // if>>
+		if t.anyChild != nil && t.anyChild.path != "" {
+			return t.anyChild, append(values, "")
+		} // if>>
+		return nil, values
+	} // if>
+
+	if i := strings.IndexByte(t.indices, path[0]); i >= 0 {
+		child := t.children[i]
+		if strings.HasPrefix(path, child.prefix) {
+			if n, v := child.search(path[len(child.prefix):], values); n != nil {
+				return n, v
+			} // if>>>
+		} // if>>
+	} // if>
+
+	if len(t.paramChildren) > 0 {
+		i := strings.IndexByte(path, '/')
+		if i < 0 {
+			i = len(path)
+		} // if>>
+		for _, child := range t.paramChildren {
+			if i == 0 || child.constraint != nil && !child.constraint.match(path[:i]) {
+				continue
+			} // if>>>
+			if n, v := child.search(path[i:], append(values, path[:i])); n != nil {
+				return n, v
+			} // if>>>
+		} // for>>
+	} // if>
+
+	if t.anyChild != nil && t.anyChild.path != "" {
+		return t.anyChild, append(values, path)
+	} // if>
+	return nil, values
 }
 
 func (t *treeNode) addHandler(method string, h HandlerFunc) {
 	switch method {
+	case http.MethodConnect:
+		t.methodHandler.connect = h
+	case http.MethodDelete:
+		t.methodHandler.delete = h
 	case http.MethodGet:
 		t.methodHandler.get = h
+	case http.MethodHead:
+		t.methodHandler.head = h
+	case http.MethodOptions:
+		t.methodHandler.options = h
+	case http.MethodPatch:
+		t.methodHandler.patch = h
 	case http.MethodPost:
 		t.methodHandler.post = h
+	case http.MethodPut:
+		t.methodHandler.put = h
+	case http.MethodTrace:
+		t.methodHandler.trace = h
+	default:
+		if t.methodHandler.custom == nil {
+			t.methodHandler.custom = make(map[string]HandlerFunc)
+		} // if>>
+		t.methodHandler.custom[method] = h
 	}
+	t.allowHeader = t.allowedMethods(false)
+	t.autoAllowHeader = t.allowedMethods(true)
+}
+
+// allowedMethods returns the registered methods of the node as an Allow header value,
+// with auto set HEAD and OPTIONS are included as they are answered automatically.
+func (t *treeNode) allowedMethods(auto bool) string {
+	allowed := make([]string, 0, len(methods))
+	for _, m := range methods {
+		switch {
+		case t.findHandler(m) != nil,
+			auto && m == http.MethodHead && t.methodHandler.get != nil,
+			auto && m == http.MethodOptions:
+			allowed = append(allowed, m)
+		} // switch>>
+	} // for>
+	custom := make([]string, 0, len(t.methodHandler.custom))
+	for m := range t.methodHandler.custom {
+		custom = append(custom, m)
+	} // for>
+	sort.Strings(custom)
+	return strings.Join(append(allowed, custom...), ", ")
 }
 
 func (t *treeNode) findHandler(method string) HandlerFunc {
 	switch method {
+	case http.MethodConnect:
+		return t.methodHandler.connect
+	case http.MethodDelete:
+		return t.methodHandler.delete
 	case http.MethodGet:
 		return t.methodHandler.get
+	case http.MethodHead:
+		return t.methodHandler.head
+	case http.MethodOptions:
+		return t.methodHandler.options
+	case http.MethodPatch:
+		return t.methodHandler.patch
 	case http.MethodPost:
 		return t.methodHandler.post
+	case http.MethodPut:
+		return t.methodHandler.put
+	case http.MethodTrace:
+		return t.methodHandler.trace
 	default:
-		return NotFoundHandler
+		return t.methodHandler.custom[method]
 	}
 }
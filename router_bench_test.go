@@ -0,0 +1,181 @@
+package tong
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// arrayTrie is the 128-way array trie replaced by the radix tree,
+// it is kept as the baseline of the benchmarks below.
+type arrayTrie struct {
+	next       [128]*arrayTrie
+	paramChild *arrayTrie
+	anyChild   *arrayTrie
+	path       string
+}
+
+func (t *arrayTrie) insert(path string) {
+	cur := t
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case ':':
+			j := i + 1
+			for j < len(path) && path[j] != '/' {
+				j++
+			}
+			if cur.paramChild == nil {
+				cur.paramChild = new(arrayTrie)
+			}
+			cur = cur.paramChild
+			i = j - 1
+		case '*':
+			if cur.anyChild == nil {
+				cur.anyChild = new(arrayTrie)
+			}
+			cur = cur.anyChild
+			i = len(path)
+		default:
+			if cur.next[path[i]] == nil {
+				cur.next[path[i]] = new(arrayTrie)
+			}
+			cur = cur.next[path[i]]
+		}
+	}
+	cur.path = path
+}
+
+func (t *arrayTrie) search(path string, values []string) (*arrayTrie, []string) {
+	if path == "" {
+		if t.path != "" {
+			return t, values
+		}
+		return nil, values
+	}
+	if c := path[0]; c < 128 && t.next[c] != nil {
+		if n, v := t.next[c].search(path[1:], values); n != nil {
+			return n, v
+		}
+	}
+	if t.paramChild != nil {
+		i := 0
+		for i < len(path) && path[i] != '/' {
+			i++
+		}
+		if i > 0 {
+			if n, v := t.paramChild.search(path[i:], append(values, path[:i])); n != nil {
+				return n, v
+			}
+		}
+	}
+	if t.anyChild != nil && t.anyChild.path != "" {
+		return t.anyChild, append(values, path)
+	}
+	return nil, values
+}
+
+// benchRoutes returns 1200 routes mixing static, param and wildcard paths.
+func benchRoutes() []string {
+	routes := make([]string, 0, 1200)
+	for i := 0; i < 300; i++ {
+		routes = append(routes,
+			fmt.Sprintf("/api/v1/resource%d", i),
+			fmt.Sprintf("/api/v1/resource%d/:id", i),
+			fmt.Sprintf("/api/v1/resource%d/:id/items/:item", i),
+			fmt.Sprintf("/static/bucket%d/*filepath", i),
+		)
+	}
+	return routes
+}
+
+var benchPaths = map[string]string{
+	"Static":   "/api/v1/resource250",
+	"Param":    "/api/v1/resource250/42/items/7",
+	"Wildcard": "/static/bucket250/css/site.css",
+}
+
+func buildRouter(routes []string) *Router {
+	r := NewRouter()
+	for _, p := range routes {
+		if err := r.Add(http.MethodGet, p, testHandler(p)); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+func benchRouter(routes []string) (*Router, *Context) {
+	return buildRouter(routes), newTestTong().NewContext(nil, httptest.NewRecorder())
+}
+
+func benchmarkRadix(b *testing.B, path string) {
+	r, c := benchRouter(benchRoutes())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Find(http.MethodGet, path, c)
+	}
+}
+
+func benchmarkArrayTrie(b *testing.B, path string) {
+	t := new(arrayTrie)
+	for _, p := range benchRoutes() {
+		t.insert(p)
+	}
+	values := make([]string, 0, 2)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		t.search(path, values[:0])
+	}
+}
+
+func BenchmarkRadixStatic(b *testing.B)       { benchmarkRadix(b, benchPaths["Static"]) }
+func BenchmarkRadixParam(b *testing.B)        { benchmarkRadix(b, benchPaths["Param"]) }
+func BenchmarkRadixWildcard(b *testing.B)     { benchmarkRadix(b, benchPaths["Wildcard"]) }
+func BenchmarkArrayTrieStatic(b *testing.B)   { benchmarkArrayTrie(b, benchPaths["Static"]) }
+func BenchmarkArrayTrieParam(b *testing.B)    { benchmarkArrayTrie(b, benchPaths["Param"]) }
+func BenchmarkArrayTrieWildcard(b *testing.B) { benchmarkArrayTrie(b, benchPaths["Wildcard"]) }
+
+func BenchmarkRadixBuild(b *testing.B) {
+	routes := benchRoutes()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buildRouter(routes)
+	}
+}
+
+func BenchmarkArrayTrieBuild(b *testing.B) {
+	routes := benchRoutes()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		t := new(arrayTrie)
+		for _, p := range routes {
+			t.insert(p)
+		}
+	}
+}
+
+// the array trie can not store non-ASCII paths, so these run on the radix tree only.
+func BenchmarkRadixUTF8(b *testing.B) {
+	routes := append(benchRoutes(), "/文档/:章节")
+	r, c := benchRouter(routes)
+	path, raw := parsePath(httptest.NewRequest(http.MethodGet, "/%E6%96%87%E6%A1%A3/%E7%AE%80%E4%BB%8B", nil))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.find(http.MethodGet, path, raw, c)
+	}
+}
+
+func BenchmarkRadixPercentEncoded(b *testing.B) {
+	routes := append(benchRoutes(), "/files/:name")
+	r, c := benchRouter(routes)
+	path, raw := parsePath(httptest.NewRequest(http.MethodGet, "/files/a%2Fb.txt", nil))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.find(http.MethodGet, path, raw, c)
+	}
+}
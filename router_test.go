@@ -0,0 +1,524 @@
+package tong
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ming3000/tong/common"
+)
+
+func testHandler(name string) HandlerFunc {
+	return func(c *Context) error {
+		return c.String(http.StatusOK, name)
+	}
+}
+
+func TestRouterParam(t *testing.T) {
+	tong := newTestTong()
+	tong.GET("/user/:id", testHandler("user"))
+	tong.GET("/user/:id/files/:file", testHandler("file"))
+	tong.GET("/user/new", testHandler("new"))
+	tong.GET("/static/*filepath", testHandler("static"))
+
+	cases := []struct {
+		path    string
+		body    string
+		pattern string
+		params  map[string]string
+	}{
+		{"/user/42", "user", "/user/:id", map[string]string{"id": "42"}},
+		{"/user/new", "new", "/user/new", map[string]string{}},
+		{"/user/newer", "user", "/user/:id", map[string]string{"id": "newer"}},
+		{"/user/7/files/a.txt", "file", "/user/:id/files/:file", map[string]string{"id": "7", "file": "a.txt"}},
+		{"/static/css/site.css", "static", "/static/*filepath", map[string]string{"filepath": "css/site.css"}},
+	}
+	for _, tc := range cases {
+		c := tong.NewContext(httptest.NewRequest(http.MethodGet, tc.path, nil), httptest.NewRecorder())
+		tong.router.Find(http.MethodGet, tc.path, c)
+		if c.Path() != tc.pattern {
+			t.Errorf("%s: pattern = %q, want %q", tc.path, c.Path(), tc.pattern)
+		}
+		if len(c.ParamNames()) != len(tc.params) {
+			t.Errorf("%s: params = %v, want %v", tc.path, c.ParamNames(), tc.params)
+		}
+		for k, v := range tc.params {
+			if got := c.Param(k); got != v {
+				t.Errorf("%s: Param(%q) = %q, want %q", tc.path, k, got, v)
+			}
+		}
+
+		rec := httptest.NewRecorder()
+		tong.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if rec.Body.String() != tc.body {
+			t.Errorf("%s: body = %q, want %q", tc.path, rec.Body.String(), tc.body)
+		}
+	}
+}
+
+func TestRouterMethods(t *testing.T) {
+	tong := newTestTong()
+	for _, m := range methods {
+		tong.Add(m, "/res", testHandler(m))
+	}
+	tong.Add("PROPFIND", "/res", testHandler("PROPFIND"))
+	tong.Any("/any", testHandler("any"))
+	tong.Match([]string{http.MethodPut, http.MethodPatch}, "/match", testHandler("match"))
+
+	for _, m := range append(methods[:], "PROPFIND") {
+		rec := httptest.NewRecorder()
+		tong.ServeHTTP(rec, httptest.NewRequest(m, "/res", nil))
+		if m != http.MethodHead && rec.Body.String() != m {
+			t.Errorf("%s /res: body = %q", m, rec.Body.String())
+		}
+		rec = httptest.NewRecorder()
+		tong.ServeHTTP(rec, httptest.NewRequest(m, "/any", nil))
+		if m != "PROPFIND" && m != http.MethodHead && rec.Body.String() != "any" {
+			t.Errorf("%s /any: body = %q", m, rec.Body.String())
+		}
+	}
+	rec := httptest.NewRecorder()
+	tong.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/match", nil))
+	if rec.Body.String() != "match" {
+		t.Errorf("PATCH /match: body = %q", rec.Body.String())
+	}
+}
+
+func TestAddRejectsInvalidRoute(t *testing.T) {
+	for _, tc := range []struct {
+		method string
+		h      HandlerFunc
+	}{
+		{"", testHandler("empty")},
+		{"GET /", testHandler("space")},
+		{http.MethodGet, nil},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Add(%q) with handler %v did not panic", tc.method, tc.h != nil)
+				}
+			}()
+			newTestTong().Add(tc.method, "/", tc.h)
+		}()
+	}
+}
+
+func TestRouterNotFoundAndMethodNotAllowed(t *testing.T) {
+	tong := newTestTong()
+	tong.GET("/user/:id", testHandler("get"))
+	tong.DELETE("/user/:id", testHandler("delete"))
+	tong.Add("PROPFIND", "/user/:id", testHandler("propfind"))
+
+	rec := httptest.NewRecorder()
+	tong.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET /nowhere: code = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	rec = httptest.NewRecorder()
+	tong.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/user/1", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /user/1: code = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+	if allow := rec.Header().Get("Allow"); allow != "DELETE, GET, HEAD, OPTIONS, PROPFIND" {
+		t.Errorf("POST /user/1: Allow = %q", allow)
+	}
+
+	tong.NotFoundHandler = testHandler("custom 404")
+	tong.MethodNotAllowedHandler = testHandler("custom 405")
+	rec = httptest.NewRecorder()
+	tong.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+	if rec.Body.String() != "custom 404" {
+		t.Errorf("custom NotFoundHandler: body = %q", rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	tong.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/user/1", nil))
+	if rec.Body.String() != "custom 405" {
+		t.Errorf("custom MethodNotAllowedHandler: body = %q", rec.Body.String())
+	}
+}
+
+// newTestTong returns a Tong whose logger writes nowhere, so the tests
+// leave no log files behind and no background work of the file logger.
+func newTestTong() *Tong {
+	tong := New()
+	tong.Logger = common.NewWriterLogger(ioutil.Discard, "", tong.Debug)
+	return tong
+}
+
+func traceMiddleware(name string) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			c.Response().Header().Add("X-Trace", name)
+			return next(c)
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+	tong := newTestTong()
+	api := tong.Group("/api", traceMiddleware("api"))
+	v1 := api.Group("/v1", traceMiddleware("v1"))
+	v1.GET("/user/:id", func(c *Context) error {
+		return c.String(http.StatusOK, c.Path()+" "+c.Param("id"))
+	}, traceMiddleware("route"))
+	api.Use(traceMiddleware("late"))
+	api.POST("/ping", testHandler("pong"))
+
+	rec := httptest.NewRecorder()
+	tong.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/user/9", nil))
+	if rec.Body.String() != "/api/v1/user/:id 9" {
+		t.Errorf("body = %q", rec.Body.String())
+	}
+	if trace := strings.Join(rec.Header()["X-Trace"], ","); trace != "api,v1,route" {
+		t.Errorf("middleware order = %q, want %q", trace, "api,v1,route")
+	}
+
+	rec = httptest.NewRecorder()
+	tong.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/ping", nil))
+	if trace := strings.Join(rec.Header()["X-Trace"], ","); trace != "api,late" {
+		t.Errorf("middleware order = %q, want %q", trace, "api,late")
+	}
+}
+
+func TestRouterAutoHeadOptions(t *testing.T) {
+	tong := newTestTong()
+	tong.GET("/doc", testHandler("document"))
+	tong.POST("/doc", testHandler("created"))
+
+	rec := httptest.NewRecorder()
+	tong.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/doc", nil))
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Errorf("HEAD /doc: code = %d, body = %q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	tong.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/doc", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("OPTIONS /doc: code = %d", rec.Code)
+	}
+	if allow := rec.Header().Get("Allow"); allow != "GET, HEAD, OPTIONS, POST" {
+		t.Errorf("OPTIONS /doc: Allow = %q", allow)
+	}
+
+	tong.DisableAutoHeadOptions = true
+	rec = httptest.NewRecorder()
+	tong.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/doc", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("HEAD /doc with auto disabled: code = %d", rec.Code)
+	}
+	if allow := rec.Header().Get("Allow"); allow != "GET, POST" {
+		t.Errorf("HEAD /doc with auto disabled: Allow = %q", allow)
+	}
+}
+
+func TestReverse(t *testing.T) {
+	tong := newTestTong()
+	tong.GET("/user/:id", testHandler("user")).SetName("user.show")
+	tong.GET("/user/:id/files/*path", testHandler("file")).SetName("user.file")
+	show := func(c *Context) error { return nil }
+	tong.GET("/about", show)
+
+	cases := []struct {
+		name   string
+		params []interface{}
+		url    string
+		err    bool
+	}{
+		{"user.show", []interface{}{"id", 42}, "/user/42", false},
+		{"user.show", []interface{}{"id", "a b", "tab", "posts", "page", 2}, "/user/a%20b?page=2&tab=posts", false},
+		{"user.file", []interface{}{"id", 1, "path", "docs/a.txt"}, "/user/1/files/docs/a.txt", false},
+		{handlerName(show), nil, "/about", false},
+		{"user.show", nil, "", true},
+		{"user.show", []interface{}{"id"}, "", true},
+		{"user.none", nil, "", true},
+	}
+	for _, tc := range cases {
+		url, err := tong.Reverse(tc.name, tc.params...)
+		if (err != nil) != tc.err || url != tc.url {
+			t.Errorf("Reverse(%q, %v) = %q, %v; want %q, error %v", tc.name, tc.params, url, err, tc.url, tc.err)
+		}
+	}
+}
+
+func TestRouterUTF8AndEncodedPaths(t *testing.T) {
+	tong := newTestTong()
+	tong.GET("/文档/:章节", func(c *Context) error {
+		return c.String(http.StatusOK, c.Param("章节"))
+	})
+	tong.GET("/files/:name", func(c *Context) error {
+		return c.String(http.StatusOK, c.Param("name"))
+	})
+
+	// the params are decoded whether the path is matched on URL.RawPath or not
+	for path, want := range map[string]string{
+		"/%E6%96%87%E6%A1%A3/%E7%AE%80%E4%BB%8B": "简介",
+		"/files/a%2Fb.txt":                       "a/b.txt",
+		"/files/a%20b":                           "a b",
+		"/files/a%20b%2Fc":                       "a b/c",
+		"/files/100%25.txt":                      "100%.txt",
+		"/%E6%96%87%E6%A1%A3/ab":                 "ab",
+		"/%E6%96%87%E6%A1%A3/a%2Fb":              "a/b",
+		"/%e6%96%87%e6%a1%a3/a%2fb%25":           "a/b%",
+	} {
+		rec := httptest.NewRecorder()
+		tong.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Body.String() != want {
+			t.Errorf("GET %s: body = %q, want %q", path, rec.Body.String(), want)
+		}
+	}
+}
+
+func TestRouterFindDoesNotAllocate(t *testing.T) {
+	r, c := benchRouter(benchRoutes())
+	for name, path := range benchPaths {
+		if n := testing.AllocsPerRun(100, func() { r.Find(http.MethodGet, path, c) }); n != 0 {
+			t.Errorf("%s: Find allocates %v times", name, n)
+		}
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	tong := newTestTong()
+	tong.POST("/b", testHandler("b"))
+	tong.GET("/b", testHandler("b"))
+	tong.Group("/a", traceMiddleware("a")).GET("/x", testHandler("x")).SetName("a.x")
+	tong.AddRoutesEndpoint()
+
+	var got []string
+	for _, r := range tong.Routes() {
+		got = append(got, r.Method+" "+r.Path)
+	}
+	want := "GET /_tong/routes,GET /a/x,GET /b,POST /b"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("Routes() = %v, want %v", got, want)
+	}
+	ax := tong.Routes()[1]
+	if ax.Group != "/a" || len(ax.Middleware) != 1 || !strings.Contains(ax.Middleware[0], "traceMiddleware") {
+		t.Errorf("route /a/x: group = %q, middleware = %v", ax.Group, ax.Middleware)
+	}
+
+	rec := httptest.NewRecorder()
+	tong.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, RoutesPath, nil))
+	if !strings.Contains(rec.Body.String(), `"name":"a.x"`) {
+		t.Errorf("GET %s: body = %s", RoutesPath, rec.Body.String())
+	}
+}
+
+func TestRouteConflicts(t *testing.T) {
+	r := NewRouter()
+	for _, p := range []string{"/user/:id", "/user/:id/posts", "/static/*path"} {
+		if err := r.Add(http.MethodGet, p, testHandler(p)); err != nil {
+			t.Fatalf("Add(%s): %v", p, err)
+		}
+	}
+	if err := r.Add(http.MethodPost, "/user/:id", testHandler("post")); err != nil {
+		t.Errorf("Add(POST /user/:id): %v", err)
+	}
+	for _, p := range []string{"/user/:id", "/user/:uid/comments", "/static/*file"} {
+		if _, ok := r.Add(http.MethodGet, p, testHandler(p)).(*RouteConflictError); !ok {
+			t.Errorf("Add(GET %s) did not report a conflict", p)
+		}
+	}
+
+	// the conflicting routes are rejected, the registered ones keep working
+	tong := newTestTong()
+	tong.GET("/user/:id", func(c *Context) error {
+		return c.String(http.StatusOK, "first "+c.Param("id"))
+	})
+	for _, ri := range []*RouteInfo{
+		tong.GET("/user/:id", testHandler("second")),
+		tong.POST("/user/:name", testHandler("post")),
+	} {
+		if _, ok := ri.Err.(*RouteConflictError); !ok {
+			t.Errorf("%s %s: Err = %v", ri.Method, ri.Path, ri.Err)
+		}
+	}
+	rec := httptest.NewRecorder()
+	tong.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/user/5", nil))
+	if rec.Body.String() != "first 5" {
+		t.Errorf("GET /user/5 after conflicts: body = %q", rec.Body.String())
+	}
+	if len(tong.Routes()) != 1 {
+		t.Errorf("rejected routes are listed: %v", tong.Routes())
+	}
+
+	tong = newTestTong()
+	tong.StrictRouting = true
+	tong.GET("/user/:id", testHandler("first"))
+	defer func() {
+		if err, ok := recover().(*RouteConflictError); !ok {
+			t.Errorf("duplicate route in strict mode: recovered %v", err)
+		}
+	}()
+	tong.GET("/user/:id", testHandler("second"))
+}
+
+func TestRouterPathPolicies(t *testing.T) {
+	tong := newTestTong()
+	tong.GET("/users", testHandler("users"))
+	tong.POST("/users", testHandler("created"))
+	tong.GET("/admin/:section/", testHandler("admin"))
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		tong.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	if rec := serve(http.MethodGet, "/users/"); rec.Code != http.StatusNotFound {
+		t.Errorf("strict GET /users/: code = %d", rec.Code)
+	}
+
+	tong.Router().TrailingSlash = PathRedirect
+	tong.Router().CleanPath = PathRedirect
+	tong.Router().CaseInsensitive = PathRedirect
+	for _, tc := range []struct {
+		method, target, location string
+		code                     int
+	}{
+		{http.MethodGet, "/users/?page=2", "/users?page=2", http.StatusMovedPermanently},
+		{http.MethodPost, "/users/", "/users", http.StatusPermanentRedirect},
+		{http.MethodGet, "//users/../admin/Logs", "/admin/Logs/", http.StatusMovedPermanently},
+		{http.MethodGet, "/USERS", "/users", http.StatusMovedPermanently},
+		{http.MethodGet, "/Admin/Logs/", "/admin/Logs/", http.StatusMovedPermanently},
+	} {
+		rec := serve(tc.method, tc.target)
+		if rec.Code != tc.code || rec.Header().Get("Location") != tc.location {
+			t.Errorf("%s %s: code = %d, Location = %q; want %d, %q",
+				tc.method, tc.target, rec.Code, rec.Header().Get("Location"), tc.code, tc.location)
+		}
+	}
+
+	tong.Router().TrailingSlash = PathMatch
+	tong.Router().CleanPath = PathMatch
+	if rec := serve(http.MethodGet, "/./users/"); rec.Body.String() != "users" {
+		t.Errorf("match GET /./users/: code = %d, body = %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHostRouting(t *testing.T) {
+	tong := newTestTong()
+	tong.GET("/", testHandler("default"))
+	tong.Host("api.example.com").GET("/", testHandler("api"))
+	tenant := tong.Host("{tenant}.example.com", traceMiddleware("tenant"))
+	tenant.Group("/users").GET("/:id", func(c *Context) error {
+		return c.String(http.StatusOK, c.Param("tenant")+" "+c.Param("id"))
+	})
+
+	for _, tc := range []struct{ host, path, body string }{
+		{"api.example.com", "/", "api"},
+		{"API.example.com:8080", "/", "api"},
+		{"acme.example.com", "/users/7", "acme 7"},
+		{"other.org", "/", "default"},
+		{"a.b.example.com", "/", "default"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Host = tc.host
+		rec := httptest.NewRecorder()
+		tong.ServeHTTP(rec, req)
+		if rec.Body.String() != tc.body {
+			t.Errorf("%s%s: body = %q, want %q", tc.host, tc.path, rec.Body.String(), tc.body)
+		}
+	}
+
+	routes := tong.Routes()
+	if len(routes) != 3 || routes[0].Host != "" || routes[2].Host != "{tenant}.example.com" {
+		t.Errorf("Routes() hosts = %q, %q, %q", routes[0].Host, routes[1].Host, routes[2].Host)
+	}
+}
+
+func TestRouterParamConstraints(t *testing.T) {
+	tong := newTestTong()
+	tong.GET("/user/:id<int>", func(c *Context) error {
+		return c.String(http.StatusOK, fmt.Sprint("id ", c.ParamInt("id", -1)))
+	})
+	tong.GET("/user/:uid<uuid>", func(c *Context) error {
+		return c.String(http.StatusOK, "uuid "+c.ParamUUID("uid", ""))
+	})
+	tong.GET("/user/:name", func(c *Context) error {
+		return c.String(http.StatusOK, "name "+c.Param("name"))
+	})
+	tong.GET("/file/:name<[a-z0-9_-]+>", testHandler("file"))
+	tong.GET("/date/:d<date>", func(c *Context) error {
+		return c.String(http.StatusOK, "date "+c.Param("d"))
+	}).SetName("date")
+
+	for path, want := range map[string]string{
+		"/user/42": "id 42",
+		"/user/6BA7B810-9DAD-11D1-80B4-00C04FD430C8": "uuid 6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		"/user/bob":        "name bob",
+		"/file/report_1":   "file",
+		"/file/Report.pdf": "Not Found",
+		"/date/2020-02-29": "date 2020-02-29",
+		"/date/2021-02-29": "Not Found",
+	} {
+		rec := httptest.NewRecorder()
+		tong.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Body.String() != want {
+			t.Errorf("GET %s: body = %q, want %q", path, rec.Body.String(), want)
+		}
+	}
+
+	if url, err := tong.Reverse("date", "d", "2020-01-01"); err != nil || url != "/date/2020-01-01" {
+		t.Errorf("Reverse(date) = %q, %v", url, err)
+	}
+	r := NewRouter()
+	for _, path := range []string{"/bad/:x<[a-z>", "/file/:p<[a-z]+/[a-z]+>", "/file/:p<[a-z]+"} {
+		if err := r.Add(http.MethodGet, path, testHandler("bad")); err == nil {
+			t.Errorf("Add(%s) did not fail", path)
+		}
+	}
+	if len(r.root.children) != 0 || r.maxParams != 0 {
+		t.Error("the failed routes changed the tree")
+	}
+}
+
+func TestMountAndWrap(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("mux " + r.URL.Path))
+	})
+	sub := newTestTong()
+	sub.GET("/user/:id", func(c *Context) error {
+		return c.String(http.StatusOK, "sub "+c.Param("id"))
+	})
+
+	tong := newTestTong()
+	tong.Mount("/debug", mux)
+	tong.Group("/api").Mount("/v2/", sub)
+	header := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Wrapped", "yes")
+			next.ServeHTTP(w, r)
+		})
+	}
+	tong.GET("/wrapped", testHandler("wrapped"), WrapMiddleware(header))
+
+	for path, want := range map[string]string{
+		"/debug/ping":      "mux /ping",
+		"/api/v2/user/3":   "sub 3",
+		"/api/v2/missing":  "Not Found",
+		"/wrapped":         "wrapped",
+		"/elsewhere/ping":  "Not Found",
+		"/debug/ping?x=1":  "mux /ping",
+		"/api/v2/user/3/x": "Not Found",
+	} {
+		rec := httptest.NewRecorder()
+		tong.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Body.String() != want {
+			t.Errorf("GET %s: body = %q, want %q", path, rec.Body.String(), want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	tong.HTTPMiddleware(traceMiddleware("converted"))(tong.HTTPHandler(testHandler("h"))).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Body.String() != "h" || rec.Header().Get("X-Trace") != "converted" {
+		t.Errorf("converted handler: body = %q, X-Trace = %q", rec.Body.String(), rec.Header().Get("X-Trace"))
+	}
+}
@@ -0,0 +1,168 @@
+package tong
+
+import (
+	"fmt"
+	"html"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ming3000/tong/common"
+)
+
+// StaticConfig configures the serving of a directory.
+type StaticConfig struct {
+	// Root is the file system to serve, e.g. http.Dir("public")
+	// or http.FS of an embed.FS.
+	Root http.FileSystem
+	// Index is the file served for a directory, index.html by default.
+	Index string
+	// Browse lists the files of a directory without index file.
+	Browse bool
+	// MaxAge sets the Cache-Control max-age of the files if it is not zero.
+	MaxAge time.Duration
+	// Dotfiles serves the files and directories whose name starts with a dot,
+	// like .git or .env, which are not found by default.
+	Dotfiles bool
+}
+
+// Static serves the files of dir under prefix, e.g. Static("/assets", "public").
+func (t *Tong) Static(prefix, dir string, m ...MiddlewareFunc) []*RouteInfo {
+	return t.StaticFS(prefix, StaticConfig{Root: http.Dir(dir)}, m...)
+}
+
+// StaticFS serves the files of config.Root under prefix.
+// the request path can not escape the root, files and directories
+// whose name starts with a dot are hidden unless config.Dotfiles.
+func (t *Tong) StaticFS(prefix string, config StaticConfig, m ...MiddlewareFunc) []*RouteInfo {
+	if config.Index == "" {
+		config.Index = "index.html"
+	} // if>
+	h := func(c *Context) error {
+		return serveFile(c, config, c.Param("*"))
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	routes := []*RouteInfo{t.GET(prefix+"/*", h, m...)}
+	if prefix != "" {
+		routes = append(routes, t.GET(prefix, h, m...))
+	} // if>
+	return routes
+}
+
+// File serves the file for path, e.g. File("/favicon.ico", "public/favicon.ico").
+func (t *Tong) File(path, file string, m ...MiddlewareFunc) *RouteInfo {
+	return t.GET(path, func(c *Context) error {
+		return c.File(file)
+	}, m...)
+}
+
+// File sends the content of the file, which may be a dotfile.
+func (c *Context) File(file string) error {
+	dir, name := filepath.Split(file)
+	return serveFile(c, StaticConfig{Root: http.Dir(dir), Index: "index.html", Dotfiles: true}, name)
+}
+
+// FileFS sends the content of the file name of fs.
+func (c *Context) FileFS(name string, fs http.FileSystem) error {
+	return serveFile(c, StaticConfig{Root: fs, Index: "index.html"}, name)
+}
+
+// Attachment sends the file to be downloaded and saved as name.
+func (c *Context) Attachment(file, name string) error {
+	return c.contentDisposition(file, name, "attachment")
+}
+
+// Inline sends the file to be displayed in the browser, name is its suggested file name.
+func (c *Context) Inline(file, name string) error {
+	return c.contentDisposition(file, name, "inline")
+}
+
+func (c *Context) contentDisposition(file, name, dispositionType string) error {
+	c.response.Header().Set(common.HeaderContentDisposition,
+		mime.FormatMediaType(dispositionType, map[string]string{"filename": name}))
+	return c.File(file)
+}
+
+// serveFile sends the file name of config.Root,
+// Range, If-Modified-Since and If-None-Match are handled by http.ServeContent.
+func serveFile(c *Context, config StaticConfig, name string) error {
+	// cleaning a rooted path removes all the .. elements, so it stays in the root
+	name = path.Clean("/" + name)
+	if !config.Dotfiles && hasDotElement(name) {
+		return notFoundHandler(c)(c)
+	} // if>
+	f, err := config.Root.Open(name)
+	if err != nil {
+		return notFoundHandler(c)(c)
+	} // if>
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	} // if>
+
+	if fi.IsDir() {
+		if reqPath := c.request.URL.Path; !strings.HasSuffix(reqPath, "/") {
+			// relative links of the directory work only with the trailing slash,
+			// the relative Location can not be taken for another host like //docs/
+			location := url.URL{Path: "./" + path.Base(reqPath) + "/", RawQuery: c.request.URL.RawQuery}
+			return c.Redirect(http.StatusMovedPermanently, location.String())
+		} // if>>
+		index, err := config.Root.Open(path.Join(name, config.Index))
+		if err != nil {
+			if config.Browse {
+				return listDir(c, f, config.Dotfiles)
+			} // if>>>
+			return notFoundHandler(c)(c)
+		} // if>>
+		defer index.Close()
+		if fi, err = index.Stat(); err != nil {
+			return err
+		} // if>>
+		f = index
+	} // if>
+
+	header := c.response.Header()
+	header.Set(common.HeaderETag, fmt.Sprintf(`W/"%x-%x"`, fi.ModTime().Unix(), fi.Size()))
+	if config.MaxAge > 0 {
+		header.Set(common.HeaderCacheControl, fmt.Sprintf("max-age=%d", int(config.MaxAge.Seconds())))
+	} // if>
+	http.ServeContent(c.response, c.request, fi.Name(), fi.ModTime(), f)
+	return nil
+}
+
+// hasDotElement reports whether an element of the cleaned path starts with a dot.
+func hasDotElement(name string) bool {
+	return strings.Contains(name, "/.")
+}
+
+// listDir sends an html page linking the files of the directory,
+// the dotfiles are left out unless dotfiles.
+func listDir(c *Context, dir http.File, dotfiles bool) error {
+	files, err := dir.Readdir(-1)
+	if err != nil {
+		return err
+	} // if>
+	sort.Slice(files, func(i, j int) bool { return files[i].Name() < files[j].Name() })
+
+	var b strings.Builder
+	b.WriteString("<pre>\n")
+	for _, fi := range files {
+		name := fi.Name()
+		if !dotfiles && strings.HasPrefix(name, ".") {
+			continue
+		} // if>>
+		if fi.IsDir() {
+			name += "/"
+		} // if>>
+		link := url.URL{Path: name}
+		fmt.Fprintf(&b, "<a href=\"%s\">%s</a>\n", link.String(), html.EscapeString(name))
+	} // for>
+	b.WriteString("</pre>\n")
+	return c.Blob(http.StatusOK, common.MIMETextHTMLCharsetUTF8, []byte(b.String()))
+}
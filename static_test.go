@@ -0,0 +1,97 @@
+package tong
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStatic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tong-static")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"public/app.js":          "console.log('tong')",
+		"public/docs/index.html": "<h1>docs</h1>",
+		"public/img/logo.txt":    "logo",
+		"public/100%.txt":        "percent",
+		"public/.env":            "password",
+		"public/.git/config":     "config",
+		"secret.txt":             "secret",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tong := newTestTong()
+	tong.Static("/assets", filepath.Join(dir, "public"))
+	tong.StaticFS("/browse", StaticConfig{Root: http.Dir(filepath.Join(dir, "public")), Browse: true})
+	tong.StaticFS("/dotfiles", StaticConfig{Root: http.Dir(filepath.Join(dir, "public")), Dotfiles: true})
+	tong.Static("/", filepath.Join(dir, "public"))
+	tong.GET("/download", func(c *Context) error {
+		return c.Attachment(filepath.Join(dir, "secret.txt"), "my secret.txt")
+	})
+
+	serve := func(path string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		tong.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("/assets/app.js", nil)
+	if rec.Body.String() != files["public/app.js"] || rec.Header().Get("ETag") == "" || rec.Header().Get("Last-Modified") == "" {
+		t.Errorf("app.js: body = %q, header = %v", rec.Body.String(), rec.Header())
+	}
+	if rec := serve("/assets/app.js", http.Header{"If-None-Match": {rec.Header().Get("ETag")}}); rec.Code != http.StatusNotModified {
+		t.Errorf("app.js with If-None-Match: code = %d", rec.Code)
+	}
+	if rec := serve("/assets/app.js", http.Header{"Range": {"bytes=0-6"}}); rec.Code != http.StatusPartialContent || rec.Body.String() != "console" {
+		t.Errorf("app.js with Range: code = %d, body = %q", rec.Code, rec.Body.String())
+	}
+	if rec := serve("/assets/100%25.txt", nil); rec.Body.String() != files["public/100%.txt"] {
+		t.Errorf("100%%.txt: code = %d, body = %q", rec.Code, rec.Body.String())
+	}
+	if rec := serve("/assets/docs/", nil); rec.Body.String() != files["public/docs/index.html"] {
+		t.Errorf("docs/: body = %q", rec.Body.String())
+	}
+	for path, want := range map[string]string{"/assets/docs": "./docs/", "/assets/docs?v=1": "./docs/?v=1", "//docs": "./docs/"} {
+		if rec := serve(path, nil); rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != want {
+			t.Errorf("%s: code = %d, Location = %q", path, rec.Code, rec.Header().Get("Location"))
+		}
+	}
+	if rec := serve("/dotfiles/.git/config", nil); rec.Body.String() != files["public/.git/config"] {
+		t.Errorf("dotfiles .git/config: code = %d, body = %q", rec.Code, rec.Body.String())
+	}
+	for _, path := range []string{"/assets/../secret.txt", "/assets/%2e%2e/secret.txt", "/assets/img/", "/assets/missing.js",
+		"/assets/.env", "/assets/.git/config", "/.git/", "/assets/%2egit/config"} {
+		if rec := serve(path, nil); rec.Code != http.StatusNotFound {
+			t.Errorf("%s: code = %d, body = %q", path, rec.Code, rec.Body.String())
+		}
+	}
+	if rec := serve("/browse/img/", nil); !strings.Contains(rec.Body.String(), `<a href="logo.txt">logo.txt</a>`) {
+		t.Errorf("browse img/: body = %q", rec.Body.String())
+	}
+	if rec := serve("/browse/", nil); !strings.Contains(rec.Body.String(), "app.js") || strings.Contains(rec.Body.String(), ".env") {
+		t.Errorf("browse: body = %q", rec.Body.String())
+	}
+	if rec := serve("/download", nil); rec.Body.String() != "secret" ||
+		rec.Header().Get("Content-Disposition") != `attachment; filename="my secret.txt"` {
+		t.Errorf("download: body = %q, header = %v", rec.Body.String(), rec.Header())
+	}
+}
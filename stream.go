@@ -0,0 +1,161 @@
+package tong
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ming3000/tong/common"
+)
+
+// Stream sends the data read from r, every chunk is flushed to the client.
+// it stops when r is drained or the client is gone.
+func (c *Context) Stream(code int, contentType string, r io.Reader) error {
+	c.WriteContentType(contentType)
+	c.response.WriteHeader(code)
+	c.response.Flush()
+
+	done := c.request.Context().Done()
+	buf := make([]byte, 32*1024)
+	for {
+		select {
+		case <-done:
+			return c.request.Context().Err()
+		default:
+		} // select>>
+
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, werr := c.response.Write(buf[:n]); werr != nil {
+				return werr
+			} // if>>>
+			c.response.Flush()
+		} // if>>
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		} // else>>
+	} // for>
+}
+
+// $--- server-sent events ---
+
+// Event is a server-sent event, only the non-empty fields are sent.
+type Event struct {
+	ID    string
+	Event string
+	Data  string
+	Retry time.Duration
+}
+
+// EventWriter sends server-sent events to the client,
+// it is safe for concurrent use. it stops when the handler returns,
+// the later events fail with context.Canceled.
+type EventWriter struct {
+	// LastEventID is the Last-Event-ID header of a reconnecting client,
+	// the events after it should be sent again.
+	LastEventID string
+
+	// the request context and the response are kept instead of the pooled Context
+	ctx      context.Context
+	response *Response
+	lock     sync.Mutex
+}
+
+// SSE starts a server-sent events response and returns its writer.
+func (c *Context) SSE() *EventWriter {
+	head := c.response.Header()
+	head.Set(common.HeaderContentType, common.MIMETextEventStream)
+	head.Set(common.HeaderCacheControl, "no-cache")
+	head.Set(common.HeaderConnection, "keep-alive")
+	// disables the buffering of nginx
+	head.Set("X-Accel-Buffering", "no")
+	c.response.WriteHeader(http.StatusOK)
+	c.response.Flush()
+
+	ctx, cancel := context.WithCancel(c.request.Context())
+	w := &EventWriter{LastEventID: c.request.Header.Get(common.HeaderLastEventID), ctx: ctx, response: c.response}
+	c.onRelease = append(c.onRelease, func() {
+		// waits for the write in progress, the response is reused after
+		w.lock.Lock()
+		cancel()
+		w.lock.Unlock()
+	})
+	return w
+}
+
+// Done is closed when the client is gone or the handler returned.
+func (w *EventWriter) Done() <-chan struct{} {
+	return w.ctx.Done()
+}
+
+// Send sends the event, the lines of Data are sent as data fields.
+func (w *EventWriter) Send(e Event) error {
+	var b strings.Builder
+	if e.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", oneLine(e.ID))
+	} // if>
+	if e.Event != "" {
+		fmt.Fprintf(&b, "event: %s\n", oneLine(e.Event))
+	} // if>
+	if e.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", e.Retry/time.Millisecond)
+	} // if>
+	for _, line := range strings.Split(strings.Replace(e.Data, "\r\n", "\n", -1), "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	} // for>
+	b.WriteString("\n")
+	return w.write(b.String())
+}
+
+// Comment sends a comment line, which is ignored by the client.
+func (w *EventWriter) Comment(text string) error {
+	return w.write(": " + oneLine(text) + "\n\n")
+}
+
+// Heartbeat sends a comment every interval to keep the connection alive,
+// until the client is gone, the handler returns or stop is called.
+func (w *EventWriter) Heartbeat(interval time.Duration) (stop func()) {
+	quit := make(chan struct{})
+	var once sync.Once
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if w.Comment("heartbeat") != nil {
+					return
+				} // if>>>>
+			case <-w.Done():
+				return
+			case <-quit:
+				return
+			} // select>>>
+		} // for>>
+	}()
+	return func() { once.Do(func() { close(quit) }) }
+}
+
+func (w *EventWriter) write(s string) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if err := w.ctx.Err(); err != nil {
+		return err
+	} // if>
+	if _, err := io.WriteString(w.response, s); err != nil {
+		return err
+	} // if>
+	w.response.Flush()
+	return nil
+}
+
+// oneLine keeps the field of an event in one line.
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
@@ -0,0 +1,94 @@
+package tong
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStream(t *testing.T) {
+	tong := newTestTong()
+	tong.GET("/stream", func(c *Context) error {
+		return c.Stream(http.StatusOK, "text/csv", strings.NewReader("a,1\nb,2\n"))
+	})
+	rec := httptest.NewRecorder()
+	tong.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	if rec.Body.String() != "a,1\nb,2\n" || rec.Header().Get("Content-Type") != "text/csv" || !rec.Flushed {
+		t.Fatalf("unexpected response %q %q", rec.Body.String(), rec.Header().Get("Content-Type"))
+	} // if>
+}
+
+func TestSSE(t *testing.T) {
+	tong := newTestTong()
+	tong.GET("/events", func(c *Context) error {
+		w := c.SSE()
+		if err := w.Comment("resume after " + w.LastEventID); err != nil {
+			return err
+		} // if>
+		return w.Send(Event{ID: "8", Event: "progress", Data: "50%\ndone", Retry: 3 * time.Second})
+	})
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Last-Event-ID", "7")
+	rec := httptest.NewRecorder()
+	tong.ServeHTTP(rec, req)
+	if rec.Header().Get("Content-Type") != "text/event-stream" || rec.Header().Get("Cache-Control") != "no-cache" {
+		t.Fatalf("unexpected headers %v", rec.Header())
+	} // if>
+	want := ": resume after 7\n\nid: 8\nevent: progress\nretry: 3000\ndata: 50%\ndata: done\n\n"
+	if rec.Body.String() != want {
+		t.Fatalf("expected body %q, got %q", want, rec.Body.String())
+	} // if>
+}
+
+func TestSSEDisconnect(t *testing.T) {
+	tong := newTestTong()
+	errs := make(chan error, 1)
+	tong.GET("/events", func(c *Context) error {
+		w := c.SSE()
+		stop := w.Heartbeat(time.Millisecond)
+		defer stop()
+		<-w.Done()
+		errs <- w.Send(Event{Data: "late"})
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	rec := httptest.NewRecorder()
+	tong.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx))
+	if err := <-errs; err != context.Canceled {
+		t.Fatalf("expected context.Canceled after disconnect, got %v", err)
+	} // if>
+}
+
+func TestSSEStopsWithHandler(t *testing.T) {
+	tong := newTestTong()
+	var w *EventWriter
+	tong.GET("/events", func(c *Context) error {
+		w = c.SSE()
+		w.Heartbeat(time.Millisecond)
+		return nil
+	})
+	tong.GET("/next", func(c *Context) error {
+		time.Sleep(20 * time.Millisecond)
+		return c.String(http.StatusOK, "next")
+	})
+	tong.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/events", nil))
+	select {
+	case <-w.Done():
+	default:
+		t.Fatal("the writer is not done after the handler returned")
+	} // select>
+	if err := w.Send(Event{Data: "late"}); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	} // if>
+
+	// the reused Context gets no heartbeat of the previous request
+	rec := httptest.NewRecorder()
+	tong.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/next", nil))
+	if rec.Body.String() != "next" {
+		t.Fatalf("unexpected body %q", rec.Body.String())
+	} // if>
+}
@@ -0,0 +1,180 @@
+package tong
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ming3000/tong/common"
+)
+
+// TemplateRenderer renders the html/template files of a directory.
+// each page file is parsed together with the layout and the partials, and
+// is named by its path relative to Dir without extension, e.g. users/show.
+// with a layout the layout is executed, it includes the blocks defined by the
+// page, e.g. {{template "content" .}}. the func url builds the URL of a named
+// route like Tong.Reverse. in debug mode of Tong the files are parsed again
+// when they change on disk.
+type TemplateRenderer struct {
+	// Dir is the directory of the template files.
+	Dir string
+	// Ext is the extension of the template files, .html by default.
+	Ext string
+	// Layout is the layout file relative to Dir, e.g. layouts/base.html.
+	Layout string
+	// Partials are the glob patterns of the files relative to Dir shared by
+	// all the pages, e.g. partials/*.html.
+	Partials []string
+	// Funcs are added to the funcs of the templates.
+	Funcs template.FuncMap
+
+	tong      *Tong
+	lock      sync.RWMutex
+	templates map[string]*template.Template
+	stamp     string
+}
+
+// NewTemplateRenderer returns a TemplateRenderer of the files in dir for t.
+// a TemplateRenderer built as a struct literal works without a Tong too,
+// but it is not reloaded in debug mode and the func url fails.
+func NewTemplateRenderer(t *Tong, dir string) *TemplateRenderer {
+	return &TemplateRenderer{Dir: dir, Ext: ".html", tong: t}
+}
+
+func (r *TemplateRenderer) ext() string {
+	if r.Ext == "" {
+		return ".html"
+	} // if>
+	return r.Ext
+}
+
+// Load parses the template files, it is called by the first Render if not before.
+func (r *TemplateRenderer) Load() error {
+	funcs := template.FuncMap{
+		"url": func(name string, params ...interface{}) (string, error) {
+			if r.tong == nil {
+				return "", fmt.Errorf("tong: no Tong to build the URL of %q", name)
+			} // if>>>
+			return r.tong.Reverse(name, params...)
+		},
+	}
+	for name, f := range r.Funcs {
+		funcs[name] = f
+	} // for>
+
+	var shared []string
+	if r.Layout != "" {
+		shared = append(shared, filepath.Join(r.Dir, r.Layout))
+	} // if>
+	for _, pattern := range r.Partials {
+		files, err := filepath.Glob(filepath.Join(r.Dir, pattern))
+		if err != nil {
+			return err
+		} // if>>
+		shared = append(shared, files...)
+	} // for>
+	isShared := make(map[string]bool, len(shared))
+	for _, f := range shared {
+		isShared[filepath.Clean(f)] = true
+	} // for>
+
+	pages, stamp, err := r.scan()
+	if err != nil {
+		return err
+	} // if>
+	templates := make(map[string]*template.Template, len(pages))
+	for _, page := range pages {
+		if isShared[page] {
+			continue
+		} // if>>
+		// the layout is executed if there is one, otherwise the page
+		root := page
+		if r.Layout != "" {
+			root = shared[0]
+		} // if>>
+		files := append(append(make([]string, 0, len(shared)+1), shared...), page)
+		tmpl, err := template.New(filepath.Base(root)).Funcs(funcs).ParseFiles(files...)
+		if err != nil {
+			return err
+		} // if>>
+		rel, _ := filepath.Rel(r.Dir, page)
+		templates[filepath.ToSlash(strings.TrimSuffix(rel, r.ext()))] = tmpl
+	} // for>
+
+	r.lock.Lock()
+	r.templates, r.stamp = templates, stamp
+	r.lock.Unlock()
+	return nil
+}
+
+// scan returns the template files of Dir and a stamp changing with them.
+func (r *TemplateRenderer) scan() ([]string, string, error) {
+	var files []string
+	var latest time.Time
+	err := filepath.Walk(r.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		} // if>>
+		if info.IsDir() || filepath.Ext(path) != r.ext() {
+			return nil
+		} // if>>
+		files = append(files, filepath.Clean(path))
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		} // if>>
+		return nil
+	})
+	return files, fmt.Sprintf("%d-%d", len(files), latest.UnixNano()), err
+}
+
+// Render executes the template named name with data.
+func (r *TemplateRenderer) Render(w io.Writer, name string, data interface{}) error {
+	r.lock.RLock()
+	loaded, stamp := r.templates != nil, r.stamp
+	r.lock.RUnlock()
+	reload := !loaded
+	if loaded && r.tong != nil && r.tong.Debug {
+		_, current, err := r.scan()
+		if err != nil {
+			return err
+		} // if>>
+		reload = current != stamp
+	} // if>
+	if reload {
+		if err := r.Load(); err != nil {
+			return err
+		} // if>>
+	} // if>
+
+	r.lock.RLock()
+	tmpl, ok := r.templates[name]
+	r.lock.RUnlock()
+	if !ok {
+		return fmt.Errorf("tong: template %q not found", name)
+	} // if>
+	return tmpl.Execute(w, data)
+}
+
+// HTML sends the html string.
+func (c *Context) HTML(code int, html string) error {
+	return c.Blob(code, common.MIMETextHTMLCharsetUTF8, []byte(html))
+}
+
+// RenderTemplate sends the template named name of Tong.TemplateRenderer executed with data.
+// nothing is sent if the execution fails, so the error handler can answer.
+func (c *Context) RenderTemplate(code int, name string, data interface{}) error {
+	if c.tong.TemplateRenderer == nil {
+		return ErrInternalServerError.WithInternal(fmt.Errorf("tong: no TemplateRenderer to render %q", name))
+	} // if>
+	var buf bytes.Buffer
+	if err := c.tong.TemplateRenderer.Render(&buf, name, data); err != nil {
+		return err
+	} // if>
+	return c.Blob(code, common.MIMETextHTMLCharsetUTF8, buf.Bytes())
+}
@@ -0,0 +1,107 @@
+package tong
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTemplate(t *testing.T, dir, name, text string) {
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	} // if>
+	if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	} // if>
+}
+
+func TestRenderTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tong-template")
+	if err != nil {
+		t.Fatal(err)
+	} // if>
+	defer os.RemoveAll(dir)
+	writeTemplate(t, dir, "layouts/base.html", `<html>{{template "nav" .}}{{template "content" .}}</html>`)
+	writeTemplate(t, dir, "partials/nav.html", `{{define "nav"}}<a href="{{url "user" "id" .ID}}">me</a>{{end}}`)
+	writeTemplate(t, dir, "users/show.html", `{{define "content"}}<p>{{.Name}}</p>{{end}}`)
+
+	tong := newTestTong()
+	tong.Debug = true
+	tong.TemplateRenderer = NewTemplateRenderer(tong, dir)
+	tong.TemplateRenderer.Layout = "layouts/base.html"
+	tong.TemplateRenderer.Partials = []string{"partials/*.html"}
+	tong.GET("/user/:id", func(c *Context) error {
+		return c.RenderTemplate(http.StatusOK, "users/show", map[string]interface{}{"ID": c.Param("id"), "Name": "<b>tong</b>"})
+	}).SetName("user")
+	tong.GET("/missing", func(c *Context) error {
+		return c.RenderTemplate(http.StatusOK, "missing", nil)
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		tong.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	rec := get("/user/7")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/html; charset=UTF-8" {
+		t.Fatalf("unexpected response %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	} // if>
+	if want := `<html><a href="/user/7">me</a><p>&lt;b&gt;tong&lt;/b&gt;</p></html>`; rec.Body.String() != want {
+		t.Fatalf("expected body %q, got %q", want, rec.Body.String())
+	} // if>
+
+	// debug mode parses the changed files again
+	writeTemplate(t, dir, "users/show.html", `{{define "content"}}<h1>{{.Name}}</h1>{{end}}`)
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(dir, "users/show.html"), later, later); err != nil {
+		t.Fatal(err)
+	} // if>
+	if want := `<html><a href="/user/8">me</a><h1>&lt;b&gt;tong&lt;/b&gt;</h1></html>`; get("/user/8").Body.String() != want {
+		t.Fatalf("expected reloaded body %q", want)
+	} // if>
+
+	if rec := get("/missing"); rec.Code != http.StatusInternalServerError || rec.Body.Len() == 0 {
+		t.Fatalf("expected 500 for a missing template, got %d", rec.Code)
+	} // if>
+}
+
+func TestRenderTemplateWithoutLayout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tong-template")
+	if err != nil {
+		t.Fatal(err)
+	} // if>
+	defer os.RemoveAll(dir)
+	writeTemplate(t, dir, "partials/footer.html", `PARTIAL-BODY{{define "footer"}}<footer>tong</footer>{{end}}`)
+	writeTemplate(t, dir, "page.html", `<p>{{.}}</p>{{template "footer"}}`)
+
+	r := NewTemplateRenderer(newTestTong(), dir)
+	r.Partials = []string{"partials/*.html"}
+	// a renderer built as a struct literal has the defaults too
+	for _, r := range []*TemplateRenderer{r, {Dir: dir, Partials: []string{"partials/*.html"}}} {
+		var buf bytes.Buffer
+		if err := r.Render(&buf, "page", "hi"); err != nil {
+			t.Fatal(err)
+		} // if>>
+		if want := "<p>hi</p><footer>tong</footer>"; buf.String() != want {
+			t.Fatalf("expected %q, got %q", want, buf.String())
+		} // if>>
+	} // for>
+}
+
+func TestHTML(t *testing.T) {
+	tong := newTestTong()
+	tong.GET("/", func(c *Context) error {
+		return c.HTML(http.StatusOK, "<p>tong</p>")
+	})
+	rec := httptest.NewRecorder()
+	tong.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Body.String() != "<p>tong</p>" || rec.Header().Get("Content-Type") != "text/html; charset=UTF-8" {
+		t.Fatalf("unexpected response %q %q", rec.Body.String(), rec.Header().Get("Content-Type"))
+	} // if>
+}
@@ -3,15 +3,21 @@ package tong
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/ming3000/tong/common"
+	"github.com/ming3000/tong/websocket"
 	"net"
 	"net/http"
 	"reflect"
 	"runtime"
+	"sort"
 	"sync"
 	"time"
 )
 
+// RoutesPath is the path of the route listing endpoint, see Tong.AddRoutesEndpoint.
+const RoutesPath = "/_tong/routes"
+
 // $--- handler type define ---
 // HandlerFunc defines a function to serve HTTP requests
 type HandlerFunc func(c *Context) error
@@ -23,22 +29,80 @@ type ErrorHandlerFunc func(*Context, error)
 type MiddlewareFunc func(HandlerFunc) HandlerFunc
 
 // $--- default handler ---
+// NotFoundHandler is the default handler for requests matching no route.
 var NotFoundHandler = func(c *Context) error {
-	return errors.New("handler not found")
+	return ErrNotFound
+}
+
+// MethodNotAllowedHandler is the default handler for requests whose path
+// matches a route registered for other methods only,
+// the Allow header has been set by the router.
+var MethodNotAllowedHandler = func(c *Context) error {
+	return ErrMethodNotAllowed
 }
 
 // DefaultHTTPErrorHandler the default HTTP error handler.
-// it sends a string response with status code StatusInternalServerError.
+// the status code is the Code of an *HTTPError, StatusUnprocessableEntity for
+// ValidationErrors and StatusInternalServerError for the other errors.
+// the response is json if the client prefers it or the message is not a string,
+// otherwise it is plain text. the internal errors are only sent in debug mode.
+// nothing is sent if the handler already wrote the response.
 var DefaultHTTPErrorHandler = func(c *Context, err error) {
-	_ = c.String(http.StatusInternalServerError, err.Error())
+	code := http.StatusInternalServerError
+	var message interface{} = http.StatusText(code)
+	var he *HTTPError
+	var fields ValidationErrors
+	switch {
+	case errors.As(err, &fields):
+		code = http.StatusUnprocessableEntity
+		message = map[string]interface{}{
+			"message": http.StatusText(code),
+			"errors":  fields,
+		}
+	case errors.As(err, &he):
+		code, message = he.Code, he.Message
+		if c.tong.Debug && he.Internal != nil {
+			message = fmt.Sprintf("%v: %v", he.Message, he.Internal)
+		} // if>>
+	case c.tong.Debug:
+		message = err.Error()
+	} // switch>
+
+	if code >= http.StatusInternalServerError {
+		c.Logger().ErrorFormat("%s %s: %v", c.request.Method, c.request.URL.Path, err)
+	} else {
+		c.Logger().DebugFormat("%s %s: %v", c.request.Method, c.request.URL.Path, err)
+	} // else>
+	if c.response.IfHeaderBeenSet {
+		return
+	} // if>
+
+	if c.request.Method == http.MethodHead {
+		c.response.WriteHeader(code)
+		return
+	} // if>
+	text, isText := message.(string)
+	if isText && c.Negotiate(common.MIMETextPlain, common.MIMEApplicationJSON) != common.MIMEApplicationJSON {
+		_ = c.String(code, text)
+		return
+	} // if>
+	if isText {
+		message = map[string]interface{}{"message": text}
+	} // if>
+	_ = c.Json(code, message, "")
 }
 
 // $--- utils func ---
 // reflect name of HandlerFunc
 func handlerName(h HandlerFunc) string {
-	t := reflect.ValueOf(h).Type()
+	return funcName(h)
+}
+
+// reflect name of a function
+func funcName(f interface{}) string {
+	t := reflect.ValueOf(f).Type()
 	if t.Kind() == reflect.Func {
-		return runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+		return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
 	}
 	return t.String()
 }
@@ -52,17 +116,35 @@ func prependMiddleware(h HandlerFunc, middleware ...MiddlewareFunc) HandlerFunc
 
 // $--- tong struct define ---
 type Tong struct {
-	Server             *http.Server
-	Listener           net.Listener
-	router             *Router
-	sysMiddleware      []MiddlewareFunc
-	customerMiddleware []MiddlewareFunc
-	cronList           []*common.Cron
-	pool               sync.Pool
-	Debug              bool
-	Logger             *common.Logger
-	NotFoundHandler    HandlerFunc
-	HTTPErrorHandler   ErrorHandlerFunc
+	Server                  *http.Server
+	Listener                net.Listener
+	router                  *Router
+	hosts                   map[string]*Router
+	hostPatterns            []string
+	sysMiddleware           []MiddlewareFunc
+	customerMiddleware      []MiddlewareFunc
+	cronList                []*common.Cron
+	pool                    sync.Pool
+	Debug                   bool
+	Logger                  *common.Logger
+	NotFoundHandler         HandlerFunc
+	MethodNotAllowedHandler HandlerFunc
+	HTTPErrorHandler        ErrorHandlerFunc
+	Binder                  Binder
+	renderers               []*renderer
+	TemplateRenderer        *TemplateRenderer
+	Validator               Validator
+	Upgrader                *websocket.Upgrader
+	// DisableAutoHeadOptions turns off answering HEAD by the GET handler
+	// and OPTIONS by the Allow header when they are not registered.
+	DisableAutoHeadOptions bool
+	// CookieKeys sign and encrypt the cookies, the first key is used for new
+	// cookies and all are accepted, so a new key is prepended to rotate them.
+	CookieKeys [][]byte
+	// DisableSecureCookies keeps the cookies of SetCookie as they are when not in debug mode.
+	DisableSecureCookies bool
+	// StrictRouting makes Add panic on duplicate or ambiguous routes instead of rejecting them with a log.
+	StrictRouting bool
 }
 
 // New creates an instance of Wu
@@ -70,6 +152,7 @@ func New() *Tong {
 	tong := &Tong{Server: new(http.Server)}
 	tong.Server.Handler = tong
 	tong.router = NewRouter()
+	tong.hosts = make(map[string]*Router)
 	tong.sysMiddleware = make([]MiddlewareFunc, 0)
 	tong.customerMiddleware = make([]MiddlewareFunc, 0)
 	tong.cronList = make([]*common.Cron, 0)
@@ -79,7 +162,15 @@ func New() *Tong {
 	tong.Debug = true
 	tong.Logger = common.NewDefaultLogger(tong.Debug)
 	tong.NotFoundHandler = NotFoundHandler
+	tong.MethodNotAllowedHandler = MethodNotAllowedHandler
 	tong.HTTPErrorHandler = DefaultHTTPErrorHandler
+	tong.Binder = &DefaultBinder{}
+	tong.RegisterRenderer(common.MIMEApplicationJSON, JSONRenderer)
+	tong.RegisterRenderer(common.MIMETextPlain, TextRenderer)
+	tong.RegisterRenderer(common.MIMEApplicationXML, XMLRenderer)
+	tong.RegisterRenderer(common.MIMETextXML, XMLRenderer)
+	tong.Validator = NewValidator()
+	tong.Upgrader = &websocket.Upgrader{EnableCompression: true, ReadLimit: websocket.DefaultReadLimit}
 	return tong
 }
 
@@ -87,17 +178,16 @@ func New() *Tong {
 // it is used to serve each HTTP requests.
 func (t *Tong) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// acquire context instance
-	c := t.pool.Get().(*Context)
-	c.Reset(r, w, c.logger, common.NewDefaultLRUCache())
+	c := t.acquireContext(w, r)
 
-	h := NotFoundHandler
+	h := t.NotFoundHandler
 	if t.sysMiddleware == nil {
-		t.router.Find(r.Method, parsePath(r), c)
+		t.find(r, c)
 		h = c.Handler()
 		h = prependMiddleware(h, t.customerMiddleware...)
 	} else {
 		h = func(c *Context) error {
-			t.router.Find(r.Method, parsePath(r), c)
+			t.find(r, c)
 			h := c.Handler()
 			h = prependMiddleware(h, t.customerMiddleware...)
 			return h(c)
@@ -111,9 +201,39 @@ func (t *Tong) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Release context
+	t.releaseContext(c)
+}
+
+// acquireContext returns a Context from the pool reset for the request.
+func (t *Tong) acquireContext(w http.ResponseWriter, r *http.Request) *Context {
+	c := t.pool.Get().(*Context)
+	c.Reset(r, w, c.logger, common.NewDefaultLRUCache())
+	return c
+}
+
+// releaseContext puts the Context back to the pool,
+// after the work bound to the request, e.g. of an EventWriter, is stopped.
+func (t *Tong) releaseContext(c *Context) {
+	for i := len(c.onRelease) - 1; i >= 0; i-- {
+		c.onRelease[i]()
+		c.onRelease[i] = nil
+	} // for>
 	t.pool.Put(c)
 }
 
+// find resolves the router by the host of the request and finds the handler,
+// the params captured from the host follow the params of the path.
+func (t *Tong) find(r *http.Request, c *Context) {
+	router, names, values := t.hostRouter(r.Host)
+	path, raw := parsePath(r)
+	router.find(r.Method, path, raw, c)
+	if len(names) > 0 {
+		pnames := make([]string, 0, len(c.pnames)+len(names))
+		c.pnames = append(append(pnames, c.pnames...), names...)
+		c.pvalues = append(c.pvalues, values...)
+	} // if>
+}
+
 // Start starts an HTTP server.
 func (t *Tong) Start(address string) error {
 	t.Server.Addr = address
@@ -143,6 +263,7 @@ func (t *Tong) StartServer(s *http.Server) error {
 	if err != nil {
 		return err
 	}
+	t.printRoutes()
 	// start all cron jobs
 	t.startCronJobs()
 	return s.Serve(t.Listener)
@@ -150,6 +271,7 @@ func (t *Tong) StartServer(s *http.Server) error {
 
 func (t *Tong) NewContext(r *http.Request, w http.ResponseWriter) *Context {
 	return &Context{
+		tong:         t,
 		request:      r,
 		response:     NewResponse(w),
 		handler:      NotFoundHandler,
@@ -158,6 +280,11 @@ func (t *Tong) NewContext(r *http.Request, w http.ResponseWriter) *Context {
 	}
 }
 
+// Router returns the default router, e.g. to configure its path policies.
+func (t *Tong) Router() *Router {
+	return t.router
+}
+
 func (t *Tong) AddSysMiddleware(middleware ...MiddlewareFunc) {
 	t.sysMiddleware = append(t.sysMiddleware, middleware...)
 }
@@ -174,27 +301,153 @@ func (t *Tong) POST(p string, h HandlerFunc, m ...MiddlewareFunc) *RouteInfo {
 	return t.Add(http.MethodPost, p, h, m...)
 }
 
+func (t *Tong) PUT(p string, h HandlerFunc, m ...MiddlewareFunc) *RouteInfo {
+	return t.Add(http.MethodPut, p, h, m...)
+}
+
+func (t *Tong) DELETE(p string, h HandlerFunc, m ...MiddlewareFunc) *RouteInfo {
+	return t.Add(http.MethodDelete, p, h, m...)
+}
+
+func (t *Tong) PATCH(p string, h HandlerFunc, m ...MiddlewareFunc) *RouteInfo {
+	return t.Add(http.MethodPatch, p, h, m...)
+}
+
+func (t *Tong) HEAD(p string, h HandlerFunc, m ...MiddlewareFunc) *RouteInfo {
+	return t.Add(http.MethodHead, p, h, m...)
+}
+
+func (t *Tong) OPTIONS(p string, h HandlerFunc, m ...MiddlewareFunc) *RouteInfo {
+	return t.Add(http.MethodOptions, p, h, m...)
+}
+
+func (t *Tong) CONNECT(p string, h HandlerFunc, m ...MiddlewareFunc) *RouteInfo {
+	return t.Add(http.MethodConnect, p, h, m...)
+}
+
+func (t *Tong) TRACE(p string, h HandlerFunc, m ...MiddlewareFunc) *RouteInfo {
+	return t.Add(http.MethodTrace, p, h, m...)
+}
+
+// Any registers a route for all the standard HTTP methods.
+func (t *Tong) Any(p string, h HandlerFunc, m ...MiddlewareFunc) []*RouteInfo {
+	return t.Match(methods[:], p, h, m...)
+}
+
+// Match registers a route for each of the given HTTP methods.
+func (t *Tong) Match(methods []string, p string, h HandlerFunc, m ...MiddlewareFunc) []*RouteInfo {
+	routes := make([]*RouteInfo, 0, len(methods))
+	for _, method := range methods {
+		routes = append(routes, t.Add(method, p, h, m...))
+	} // for>
+	return routes
+}
+
+// Add registers a route for method and path with matching handler.
+// It panics if the method is not a valid HTTP method token or the handler is nil,
+// since such a route could never be served.
+// A route conflicting with the registered routes panics if StrictRouting is set,
+// otherwise the conflict is logged, the route is not registered and its
+// RouteInfo.Err tells why.
 func (t *Tong) Add(method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) *RouteInfo {
+	return t.add(nil, method, path, handler, middleware...)
+}
+
+// add registers a route to the router of the group g, or the default router if g is nil.
+func (t *Tong) add(g *Group, method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) *RouteInfo {
+	router, group, host := t.router, "", ""
+	if g != nil {
+		router, group, host = g.router, g.prefix, g.host
+	} // if>
 	name := handlerName(handler)
-	t.router.Add(method, path, func(c *Context) error {
-		h := prependMiddleware(handler, middleware...)
-		return h(c)
-	})
+	var h HandlerFunc
+	if handler != nil {
+		h = func(c *Context) error {
+			h := prependMiddleware(handler, middleware...)
+			return h(c)
+		}
+	} // if>
+	mNames := make([]string, 0, len(middleware))
+	for _, m := range middleware {
+		mNames = append(mNames, funcName(m))
+	} // for>
 	r := &RouteInfo{
-		Method: method,
-		Path:   path,
-		Name:   name,
+		Method:     method,
+		Path:       path,
+		Name:       name,
+		Group:      group,
+		Host:       host,
+		Middleware: mNames,
 	}
-	t.router.routes[method+path] = r
+
+	err := router.Add(method, path, h)
+	if conflict, ok := err.(*RouteConflictError); ok && !t.StrictRouting {
+		// the registered routes are kept as they are
+		t.Logger.ErrorFormat("%v", conflict)
+		r.Err = conflict
+		return r
+	} // if>
+	if err != nil {
+		panic(err)
+	} // if>
+	router.routes[method+path] = r
 	return r
 }
 
+// Routes returns the registered routes sorted by host, path and method.
+func (t *Tong) Routes() []*RouteInfo {
+	routes := make([]*RouteInfo, 0, len(t.router.routes))
+	for _, r := range t.router.routes {
+		routes = append(routes, r)
+	} // for>
+	for _, router := range t.hosts {
+		for _, r := range router.routes {
+			routes = append(routes, r)
+		} // for>>
+	} // for>
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Host != routes[j].Host {
+			return routes[i].Host < routes[j].Host
+		} // if>>
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		} // if>>
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// AddRoutesEndpoint registers GET RoutesPath answering the registered routes as json,
+// it is not registered by default as it exposes the structure of the application.
+func (t *Tong) AddRoutesEndpoint(m ...MiddlewareFunc) *RouteInfo {
+	return t.GET(RoutesPath, func(c *Context) error {
+		return c.Json(http.StatusOK, t.Routes(), "")
+	}, m...).SetName("tong.routes")
+}
+
+// printRoutes logs the registered routes in debug mode.
+func (t *Tong) printRoutes() {
+	if !t.Debug {
+		return
+	} // if>
+	for _, r := range t.Routes() {
+		t.Logger.DebugFormat("%-7s %-30s --> %s", r.Method, r.Host+r.Path, r.Name)
+	} // for>
+}
+
 func (t *Tong) AddCronJob(initialPeriod, stepPeriod, maxPeriod time.Duration, job common.Job) {
 	c := common.NewCron(initialPeriod, stepPeriod, maxPeriod)
 	c.Do(job)
 	t.cronList = append(t.cronList, c)
 }
 
+// AddCronJobContext adds a job whose context is canceled when the server stops.
+func (t *Tong) AddCronJobContext(initialPeriod, stepPeriod, maxPeriod time.Duration, job common.ContextJob) {
+	c := common.NewCron(initialPeriod, stepPeriod, maxPeriod)
+	c.DoContext(job)
+	t.cronList = append(t.cronList, c)
+}
+
 func (t *Tong) startCronJobs() {
 	for i := range t.cronList {
 		t.cronList[i].Start()
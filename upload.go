@@ -0,0 +1,175 @@
+package tong
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// BodyLimit limits the request body of the routes to maxSize bytes, the larger
+// requests are answered by 413. maxMemory is the memory used to parse a
+// multipart form, the files beyond it are stored in temporary files.
+// 0 keeps the default of 32 MB.
+func BodyLimit(maxSize, maxMemory int64) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			r := c.request
+			if r.ContentLength > maxSize {
+				return ErrRequestEntityTooLarge
+			} // if>>>
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = &limitedBody{ReadCloser: r.Body, remaining: maxSize}
+			} // if>>>
+			if maxMemory > 0 {
+				c.maxMemory = maxMemory
+			} // if>>>
+			return next(c)
+		}
+	}
+}
+
+// limitedBody fails the reads beyond the limit of BodyLimit.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	exceeded  bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// one more byte tells a body of exactly the limit from a larger one
+		var one [1]byte
+		if n, _ := b.ReadCloser.Read(one[:]); n > 0 {
+			b.exceeded = true
+			return 0, ErrRequestEntityTooLarge
+		} // if>>
+		return 0, io.EOF
+	} // if>
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	} // if>
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+func (c *Context) multipartMemory() int64 {
+	if c.maxMemory > 0 {
+		return c.maxMemory
+	} // if>
+	return defaultMultipartMemory
+}
+
+// bodyError returns 413 if the body is beyond BodyLimit,
+// or 400 with err of reading the body as what.
+func (c *Context) bodyError(what string, err error) error {
+	if b, ok := c.request.Body.(*limitedBody); ok && b.exceeded {
+		return ErrRequestEntityTooLarge
+	} // if>
+	return ErrBadRequest.WithInternal(fmt.Errorf("tong: %s: %v", what, err))
+}
+
+// MultipartForm parses the multipart form of the request with the memory of BodyLimit,
+// the form is parsed once.
+func (c *Context) MultipartForm() (*multipart.Form, error) {
+	if c.request.MultipartForm == nil {
+		if err := c.request.ParseMultipartForm(c.multipartMemory()); err != nil {
+			return nil, c.bodyError("multipart", err)
+		} // if>>
+	} // if>
+	return c.request.MultipartForm, nil
+}
+
+// FormFile returns the first file of the multipart form by name,
+// or http.ErrMissingFile.
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, err
+	} // if>
+	if files := form.File[name]; len(files) > 0 {
+		return files[0], nil
+	} // if>
+	return nil, http.ErrMissingFile
+}
+
+// SaveUploadedFile saves the file to dst, the directory of dst is created if missing.
+func (c *Context) SaveUploadedFile(fh *multipart.FileHeader, dst string) error {
+	src, err := fh.Open()
+	if err != nil {
+		return err
+	} // if>
+	defer src.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	} // if>
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	} // if>
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	} // if>
+	return out.Close()
+}
+
+// $--- streaming ---
+
+// MultipartReader iterates the parts of a multipart request as they are read
+// from the body, nothing is buffered, so it suits huge uploads.
+// reading a part beyond BodyLimit fails with ErrRequestEntityTooLarge.
+//
+//	for mr.Next() {
+//		part := mr.Part()
+//		...
+//	}
+//	if err := mr.Err(); err != nil {
+type MultipartReader struct {
+	c    *Context
+	r    *multipart.Reader
+	part *multipart.Part
+	err  error
+}
+
+// MultipartReader returns the iterator of the parts of the request,
+// it must not be used with MultipartForm or the Post readers.
+func (c *Context) MultipartReader() (*MultipartReader, error) {
+	r, err := c.request.MultipartReader()
+	if err != nil {
+		return nil, ErrBadRequest.WithInternal(fmt.Errorf("tong: multipart: %v", err))
+	} // if>
+	return &MultipartReader{c: c, r: r}, nil
+}
+
+// Next moves to the next part, it returns false at the end or on error.
+// the unread data of the current part is skipped.
+func (m *MultipartReader) Next() bool {
+	if m.err != nil {
+		return false
+	} // if>
+	part, err := m.r.NextPart()
+	if err == io.EOF {
+		m.part = nil
+		return false
+	} else if err != nil {
+		m.part, m.err = nil, m.c.bodyError("multipart", err)
+		return false
+	} // else>
+	m.part = part
+	return true
+}
+
+// Part returns the current part, its FormName and FileName tell a field from a file.
+func (m *MultipartReader) Part() *multipart.Part {
+	return m.part
+}
+
+// Err returns the error stopping Next, 413 if the body is beyond BodyLimit.
+func (m *MultipartReader) Err() error {
+	return m.err
+}
@@ -0,0 +1,152 @@
+package tong
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// multipartBody returns a body with the field name=tong and the file avatar of size bytes.
+func multipartBody(t *testing.T, size int) (*bytes.Buffer, string) {
+	body := new(bytes.Buffer)
+	w := multipart.NewWriter(body)
+	if err := w.WriteField("name", "tong"); err != nil {
+		t.Fatal(err)
+	} // if>
+	fw, err := w.CreateFormFile("avatar", "avatar.png")
+	if err != nil {
+		t.Fatal(err)
+	} // if>
+	fw.Write(bytes.Repeat([]byte("x"), size))
+	w.Close()
+	return body, w.FormDataContentType()
+}
+
+func uploadRequest(t *testing.T, path string, size int, chunked bool) *http.Request {
+	body, contentType := multipartBody(t, size)
+	req := httptest.NewRequest(http.MethodPost, path, body)
+	req.Header.Set("Content-Type", contentType)
+	if chunked {
+		// hides the length, like a chunked request
+		req.Body, req.ContentLength = ioutil.NopCloser(io.MultiReader(body)), -1
+	} // if>
+	return req
+}
+
+func TestFormFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tong-upload")
+	if err != nil {
+		t.Fatal(err)
+	} // if>
+	defer os.RemoveAll(dir)
+
+	tong := newTestTong()
+	handler := func(c *Context) error {
+		fh, err := c.FormFile("avatar")
+		if err != nil {
+			return err
+		} // if>>
+		if _, err := c.FormFile("missing"); err != http.ErrMissingFile {
+			t.Errorf("expected http.ErrMissingFile, got %v", err)
+		} // if>>
+		if err := c.SaveUploadedFile(fh, filepath.Join(dir, "avatars", fh.Filename)); err != nil {
+			return err
+		} // if>>
+		return c.String(http.StatusOK, c.PostString("name", ""))
+	}
+	tong.POST("/upload", handler)
+	tong.POST("/limited", handler, BodyLimit(1024, 256))
+
+	for _, tc := range []struct {
+		path    string
+		size    int
+		chunked bool
+		code    int
+	}{
+		{"/upload", 512, false, http.StatusOK},
+		{"/limited", 512, false, http.StatusOK},
+		{"/limited", 2048, false, http.StatusRequestEntityTooLarge},
+		{"/limited", 2048, true, http.StatusRequestEntityTooLarge},
+	} {
+		rec := httptest.NewRecorder()
+		tong.ServeHTTP(rec, uploadRequest(t, tc.path, tc.size, tc.chunked))
+		if rec.Code != tc.code {
+			t.Fatalf("%s %d bytes: expected %d, got %d %s", tc.path, tc.size, tc.code, rec.Code, rec.Body.String())
+		} // if>>
+		if tc.code == http.StatusOK && rec.Body.String() != "tong" {
+			t.Fatalf("unexpected body %q", rec.Body.String())
+		} // if>>
+	} // for>
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "avatars", "avatar.png"))
+	if err != nil || len(data) != 512 {
+		t.Fatalf("expected the saved file of 512 bytes, got %d %v", len(data), err)
+	} // if>
+}
+
+func TestBindBodyLimit(t *testing.T) {
+	tong := newTestTong()
+	tong.POST("/bind", func(c *Context) error {
+		var m map[string]string
+		return c.Bind(&m)
+	}, BodyLimit(64, 0))
+	for _, contentType := range []string{"application/json", "text/xml", "application/x-www-form-urlencoded"} {
+		body := strings.Repeat("a", 128)
+		req := httptest.NewRequest(http.MethodPost, "/bind", strings.NewReader(`{"name":"`+body+`"}`))
+		req.ContentLength = -1
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		tong.ServeHTTP(rec, req)
+		if rec.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s: expected 413, got %d %s", contentType, rec.Code, rec.Body.String())
+		} // if>>
+	} // for>
+}
+
+func TestMultipartReader(t *testing.T) {
+	tong := newTestTong()
+	tong.POST("/stream", func(c *Context) error {
+		mr, err := c.MultipartReader()
+		if err != nil {
+			return err
+		} // if>>
+		var parts []string
+		for mr.Next() {
+			part := mr.Part()
+			n, err := io.Copy(ioutil.Discard, part)
+			if err != nil {
+				return err
+			} // if>>>
+			parts = append(parts, part.FormName()+":"+part.FileName()+":"+strings.Repeat("x", int(n/1024)))
+		} // for>>
+		if err := mr.Err(); err != nil {
+			return err
+		} // if>>
+		return c.String(http.StatusOK, strings.Join(parts, ","))
+	}, BodyLimit(8<<10, 0))
+
+	rec := httptest.NewRecorder()
+	tong.ServeHTTP(rec, uploadRequest(t, "/stream", 4<<10, true))
+	if rec.Code != http.StatusOK || rec.Body.String() != "name::,avatar:avatar.png:xxxx" {
+		t.Fatalf("unexpected response %d %q", rec.Code, rec.Body.String())
+	} // if>
+
+	rec = httptest.NewRecorder()
+	tong.ServeHTTP(rec, uploadRequest(t, "/stream", 16<<10, true))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for a huge upload, got %d", rec.Code)
+	} // if>
+
+	rec = httptest.NewRecorder()
+	tong.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/stream", strings.NewReader("name=tong")))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a request not multipart, got %d", rec.Code)
+	} // if>
+}
@@ -1,6 +1,40 @@
 package tong
 
-import "net/http"
+import (
+	"net/http"
+	pathpkg "path"
+	"strings"
+)
+
+// methods are the standard HTTP methods registered by Tong.Any.
+var methods = [...]string{
+	http.MethodConnect,
+	http.MethodDelete,
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodOptions,
+	http.MethodPatch,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodTrace,
+}
+
+// validMethod reports whether method is a valid HTTP method token (RFC 7230).
+func validMethod(method string) bool {
+	if method == "" {
+		return false
+	} // if>
+	for i := 0; i < len(method); i++ {
+		c := method[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			continue
+		} // if>>
+		if !strings.ContainsRune("!#$%&'*+-.^_`|~", rune(c)) {
+			return false
+		} // if>>
+	} // for>
+	return true
+}
 
 // fix the input path
 func fixPath(path string) string {
@@ -13,12 +47,85 @@ func fixPath(path string) string {
 	return path
 }
 
-// GetPath returns RawPath。
-// if it's empty returns Path from URL
-func parsePath(r *http.Request) string {
-	path := r.URL.RawPath
-	if path == "" {
-		path = r.URL.Path
-	}
-	return path
+// cleanPath returns the shortest path equivalent to p like path.Clean,
+// but the trailing slash of p is kept.
+func cleanPath(p string) string {
+	cleaned := pathpkg.Clean(p)
+	if p[len(p)-1] == '/' && cleaned != "/" {
+		if len(cleaned)+1 == len(p) && strings.HasPrefix(p, cleaned) {
+			return p
+		} // if>>
+		cleaned += "/"
+	} // if>
+	return cleaned
+}
+
+// toggleTrailingSlash removes the trailing slash of p, or adds one if there is none.
+func toggleTrailingSlash(p string) string {
+	if p[len(p)-1] == '/' {
+		return p[:len(p)-1]
+	} // if>
+	return p + "/"
+}
+
+// fillPath builds a path from the route pattern and the param values.
+func fillPath(pattern string, values []string) string {
+	var b strings.Builder
+	k := 0
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case ':':
+			for i+1 < len(pattern) && pattern[i+1] != '/' {
+				i++
+			} // for>>>
+			b.WriteString(values[k])
+			k++
+		case '*':
+			b.WriteString(values[k])
+			k++
+			i = len(pattern)
+		default:
+			b.WriteByte(pattern[i])
+		} // switch>>
+	} // for>
+	return b.String()
+}
+
+// parsePath returns the path to route the request by, and whether it keeps
+// escapes. URL.RawPath is set when the path has escapes like %2F that URL.Path
+// can not keep, then the path is RawPath decoded but for %2F and %25, so the
+// static segments match as registered while a param keeps its %2F in one
+// segment. the params captured from it must be unescaped.
+func parsePath(r *http.Request) (string, bool) {
+	raw := r.URL.RawPath
+	if raw == "" {
+		return r.URL.Path, false
+	} // if>
+	var b strings.Builder
+	b.Grow(len(raw))
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '%' && i+2 < len(raw) && ishex(raw[i+1]) && ishex(raw[i+2]) {
+			if c := unhex(raw[i+1])<<4 | unhex(raw[i+2]); c != '/' && c != '%' {
+				b.WriteByte(c)
+				i += 2
+				continue
+			} // if>>>
+		} // if>>
+		b.WriteByte(raw[i])
+	} // for>
+	return b.String(), true
+}
+
+func ishex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	} // switch>
+	return c - 'A' + 10
 }
@@ -0,0 +1,303 @@
+package tong
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validator validates a value, e.g. a struct bound from the request.
+type Validator interface {
+	Validate(i interface{}) error
+}
+
+// FieldError describes a field failing a validation rule.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// ValidationErrors are the failing fields of a value,
+// DefaultHTTPErrorHandler answers them with status 422.
+type ValidationErrors []*FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fe := range e {
+		messages = append(messages, fe.Message)
+	} // for>
+	return strings.Join(messages, "; ")
+}
+
+// RuleFunc reports whether the value satisfies a rule, param is the text after '='
+// of the rule, e.g. 3 for min=3.
+type RuleFunc func(v reflect.Value, param string) bool
+
+// DefaultValidator validates the fields of a struct by their validate tags,
+// e.g. `validate:"required,min=3,email"`. the rules are
+// required, omitempty, min, max, len, oneof, email, url, alpha, alphanum, numeric
+// and uuid, custom rules are added by RegisterRule.
+// nested structs, pointers to structs and slices of structs are validated as well.
+type DefaultValidator struct {
+	rules map[string]RuleFunc
+}
+
+// NewValidator returns a DefaultValidator with the built-in rules.
+func NewValidator() *DefaultValidator {
+	return &DefaultValidator{rules: map[string]RuleFunc{
+		"required": ruleRequired,
+		"min":      ruleMin,
+		"max":      ruleMax,
+		"len":      ruleLen,
+		"oneof":    ruleOneOf,
+		"email":    stringRule(isEmail),
+		"url":      stringRule(isURL),
+		"alpha":    stringRule(isAlpha),
+		"alphanum": stringRule(isAlphaNum),
+		"numeric":  stringRule(isNumeric),
+		"uuid":     stringRule(isUUID),
+	}}
+}
+
+// RegisterRule adds or replaces a rule.
+func (v *DefaultValidator) RegisterRule(name string, rule RuleFunc) {
+	v.rules[name] = rule
+}
+
+// Validate implements the Validator interface,
+// the error is ValidationErrors if any field fails.
+func (v *DefaultValidator) Validate(i interface{}) error {
+	value := reflect.ValueOf(i)
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	} // for>
+	if value.Kind() != reflect.Struct {
+		return nil
+	} // if>
+
+	var errs ValidationErrors
+	if err := v.validateStruct(value, "", &errs); err != nil {
+		return err
+	} // if>
+	if len(errs) > 0 {
+		return errs
+	} // if>
+	return nil
+}
+
+func (v *DefaultValidator) validateStruct(value reflect.Value, prefix string, errs *ValidationErrors) error {
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		} // if>>
+		fv := value.Field(i)
+		name := prefix + fieldName(field)
+
+		if tag := field.Tag.Get("validate"); tag != "" && tag != "-" {
+			if err := v.validateField(fv, name, tag, errs); err != nil {
+				return err
+			} // if>>>
+		} // if>>
+
+		// validate the nested structs
+		for fv.Kind() == reflect.Ptr && !fv.IsNil() {
+			fv = fv.Elem()
+		} // for>>
+		switch {
+		case fv.Kind() == reflect.Struct && !isScalarType(fv.Type()):
+			if field.Anonymous {
+				name = strings.TrimSuffix(prefix, ".")
+				if name != "" {
+					name += "."
+				} // if>>>>
+			} else {
+				name += "."
+			} // else>>>
+			if err := v.validateStruct(fv, name, errs); err != nil {
+				return err
+			} // if>>>
+		case fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array:
+			for k := 0; k < fv.Len(); k++ {
+				elem := fv.Index(k)
+				for elem.Kind() == reflect.Ptr && !elem.IsNil() {
+					elem = elem.Elem()
+				} // for>>>>
+				if elem.Kind() != reflect.Struct || isScalarType(elem.Type()) {
+					break
+				} // if>>>>
+				if err := v.validateStruct(elem, fmt.Sprintf("%s[%d].", name, k), errs); err != nil {
+					return err
+				} // if>>>>
+			} // for>>>
+		} // switch>>
+	} // for>
+	return nil
+}
+
+func (v *DefaultValidator) validateField(fv reflect.Value, name, tag string, errs *ValidationErrors) error {
+	for _, r := range strings.Split(tag, ",") {
+		rule, param := r, ""
+		if k := strings.IndexByte(r, '='); k >= 0 {
+			rule, param = r[:k], r[k+1:]
+		} // if>>
+		switch rule {
+		case "omitempty":
+			if fv.IsZero() {
+				return nil
+			} // if>>>
+			continue
+		case "required":
+		default:
+			// the other rules apply to the value pointed by a pointer, if any
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					return nil
+				} // if>>>>
+				fv = fv.Elem()
+			} // if>>>
+		} // switch>>
+
+		fn, ok := v.rules[rule]
+		if !ok {
+			return fmt.Errorf("tong: unknown validation rule %q of field %s", rule, name)
+		} // if>>
+		if !fn(fv, param) {
+			*errs = append(*errs, &FieldError{
+				Field:   name,
+				Rule:    rule,
+				Param:   param,
+				Message: ruleMessage(name, rule, param),
+			})
+			return nil
+		} // if>>
+	} // for>
+	return nil
+}
+
+// fieldName returns the json name of the field, or its name if it has none.
+func fieldName(field reflect.StructField) string {
+	if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	} // if>
+	return field.Name
+}
+
+func ruleMessage(field, rule, param string) string {
+	switch rule {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "min":
+		return fmt.Sprintf("%s must be at least %s", field, param)
+	case "max":
+		return fmt.Sprintf("%s must be at most %s", field, param)
+	case "len":
+		return fmt.Sprintf("%s must have a length of %s", field, param)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of [%s]", field, param)
+	case "email":
+		return fmt.Sprintf("%s must be a valid email address", field)
+	case "url":
+		return fmt.Sprintf("%s must be a valid url", field)
+	default:
+		return fmt.Sprintf("%s must satisfy the %s rule", field, rule)
+	}
+}
+
+// $--- rules ---
+func ruleRequired(v reflect.Value, _ string) bool {
+	return !v.IsZero()
+}
+
+// sizeOf returns the number of a value for min, max and len,
+// which is the length of a string, slice or map and the value of a number.
+func sizeOf(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+func compareSize(v reflect.Value, param string, ok func(size, limit float64) bool) bool {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return false
+	} // if>
+	size, valid := sizeOf(v)
+	return valid && ok(size, limit)
+}
+
+func ruleMin(v reflect.Value, param string) bool {
+	return compareSize(v, param, func(size, limit float64) bool { return size >= limit })
+}
+
+func ruleMax(v reflect.Value, param string) bool {
+	return compareSize(v, param, func(size, limit float64) bool { return size <= limit })
+}
+
+func ruleLen(v reflect.Value, param string) bool {
+	return compareSize(v, param, func(size, limit float64) bool { return size == limit })
+}
+
+func ruleOneOf(v reflect.Value, param string) bool {
+	s := fmt.Sprint(v.Interface())
+	for _, option := range strings.Fields(param) {
+		if s == option {
+			return true
+		} // if>>
+	} // for>
+	return false
+}
+
+// stringRule makes a rule of a string check, values of other kinds fail it.
+func stringRule(check func(string) bool) RuleFunc {
+	return func(v reflect.Value, _ string) bool {
+		return v.Kind() == reflect.String && check(v.String())
+	}
+}
+
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
+
+func isURL(s string) bool {
+	u, err := url.ParseRequestURI(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+func isAlphaNum(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		} // if>>
+	} // for>
+	return s != ""
+}
+
+func isNumeric(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
@@ -0,0 +1,45 @@
+package tong
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ming3000/tong/websocket"
+)
+
+// WebSocketHandler handles an upgraded websocket connection,
+// the connection is closed when it returns.
+type WebSocketHandler func(c *Context, ws *websocket.Conn) error
+
+// WebSocket registers a GET route upgrading the requests by Tong.Upgrader.
+func (t *Tong) WebSocket(p string, h WebSocketHandler, m ...MiddlewareFunc) *RouteInfo {
+	return t.GET(p, t.webSocketHandler(h), m...)
+}
+
+// WebSocket registers a GET route of the group upgrading the requests by Tong.Upgrader.
+func (g *Group) WebSocket(p string, h WebSocketHandler, m ...MiddlewareFunc) *RouteInfo {
+	return g.GET(p, g.tong.webSocketHandler(h), m...)
+}
+
+func (t *Tong) webSocketHandler(h WebSocketHandler) HandlerFunc {
+	return func(c *Context) error {
+		// the upgrader answers the rejected handshakes itself,
+		// the headers set by the middleware are sent with the handshake
+		ws, err := t.Upgrader.Upgrade(c.Response(), c.Request(), c.Response().Header())
+		var he *websocket.HandshakeError
+		if errors.As(err, &he) {
+			// the response is written, the error of the client is not logged as 500
+			return NewHTTPError(he.Code).WithInternal(he)
+		} else if err != nil {
+			return err
+		} // else>
+
+		err = h(c, ws)
+		code, text := websocket.CloseNormalClosure, ""
+		if err != nil {
+			code, text = websocket.CloseInternalServerErr, http.StatusText(http.StatusInternalServerError)
+		} // if>
+		_ = ws.Close(code, text)
+		return err
+	}
+}
@@ -0,0 +1,135 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Dialer opens WebSocket connections as a client.
+type Dialer struct {
+	// Subprotocols are offered to the server in order of preference.
+	Subprotocols []string
+	// EnableCompression offers permessage-deflate to the server.
+	EnableCompression bool
+	// HandshakeTimeout is the timeout of dialing and the opening handshake.
+	HandshakeTimeout time.Duration
+	// TLSConfig is used for wss URLs.
+	TLSConfig *tls.Config
+}
+
+// DefaultDialer is the Dialer used by Dial.
+var DefaultDialer = &Dialer{HandshakeTimeout: 45 * time.Second}
+
+// Dial opens a connection to the ws or wss URL with DefaultDialer.
+func Dial(rawURL string, header http.Header) (*Conn, *http.Response, error) {
+	return DefaultDialer.Dial(rawURL, header)
+}
+
+// Dial opens a connection to the ws or wss URL, header is added to the handshake request.
+// the response is returned when the handshake fails too.
+func (d *Dialer) Dial(rawURL string, header http.Header) (*Conn, *http.Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, err
+	} // if>
+	secure := false
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme, secure = "https", true
+	default:
+		return nil, nil, ErrBadHandshake
+	} // switch>
+	addr := u.Host
+	if u.Port() == "" {
+		if secure {
+			addr = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			addr = net.JoinHostPort(u.Hostname(), "80")
+		} // else>>
+	} // if>
+
+	var deadline time.Time
+	if d.HandshakeTimeout > 0 {
+		deadline = time.Now().Add(d.HandshakeTimeout)
+	} // if>
+	conn, err := (&net.Dialer{Deadline: deadline}).Dial("tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	} // if>
+	if secure {
+		config := d.TLSConfig
+		if config == nil {
+			config = &tls.Config{}
+		} // if>>
+		if config.ServerName == "" {
+			config = config.Clone()
+			config.ServerName = u.Hostname()
+		} // if>>
+		conn = tls.Client(conn, config)
+	} // if>
+	_ = conn.SetDeadline(deadline)
+
+	c, resp, err := d.handshake(conn, u, header)
+	if err != nil {
+		_ = conn.Close()
+		return nil, resp, err
+	} // if>
+	_ = conn.SetDeadline(time.Time{})
+	return c, resp, nil
+}
+
+func (d *Dialer) handshake(conn net.Conn, u *url.URL, header http.Header) (*Conn, *http.Response, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	} // if>
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req := &http.Request{Method: http.MethodGet, URL: u, Host: u.Host, Header: make(http.Header)}
+	for name, values := range header {
+		req.Header[name] = values
+	} // for>
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if len(d.Subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(d.Subprotocols, ", "))
+	} // if>
+	if d.EnableCompression {
+		req.Header.Set("Sec-WebSocket-Extensions", "permessage-deflate; server_no_context_takeover; client_no_context_takeover")
+	} // if>
+	if err := req.Write(conn); err != nil {
+		return nil, nil, err
+	} // if>
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, nil, err
+	} // if>
+	if resp.StatusCode != http.StatusSwitchingProtocols ||
+		!headerContains(resp.Header, "Upgrade", "websocket") ||
+		!headerContains(resp.Header, "Connection", "upgrade") ||
+		resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		return nil, resp, ErrBadHandshake
+	} // if>
+	subprotocol := resp.Header.Get("Sec-WebSocket-Protocol")
+	if subprotocol != "" && !containsToken(d.Subprotocols, subprotocol) {
+		return nil, resp, ErrBadHandshake
+	} // if>
+	compress := offersDeflate(resp.Header)
+	if compress && !d.EnableCompression {
+		return nil, resp, ErrBadHandshake
+	} // if>
+	return newConn(conn, br, false, subprotocol, compress), resp, nil
+}
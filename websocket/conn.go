@@ -0,0 +1,525 @@
+// Package websocket implements the WebSocket protocol of RFC 6455
+// with the permessage-deflate extension of RFC 7692.
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// $--- message types ---
+const (
+	continuationFrame = 0
+	TextMessage       = 1
+	BinaryMessage     = 2
+	CloseMessage      = 8
+	PingMessage       = 9
+	PongMessage       = 10
+)
+
+// $--- close codes ---
+const (
+	CloseNormalClosure           = 1000
+	CloseGoingAway               = 1001
+	CloseProtocolError           = 1002
+	CloseUnsupportedData         = 1003
+	CloseNoStatusReceived        = 1005
+	CloseAbnormalClosure         = 1006
+	CloseInvalidFramePayloadData = 1007
+	ClosePolicyViolation         = 1008
+	CloseMessageTooBig           = 1009
+	CloseInternalServerErr       = 1011
+)
+
+const (
+	finalBit = 0x80
+	rsv1Bit  = 0x40
+	rsv23Bit = 0x30
+	maskBit  = 0x80
+
+	maxControlPayload = 125
+)
+
+// DefaultReadLimit is the read limit of the connections without one.
+const DefaultReadLimit = 32 << 20
+
+var (
+	// ErrReadLimit is returned when a message is larger than the read limit.
+	ErrReadLimit = errors.New("websocket: read limit exceeded")
+	// ErrCloseSent is returned when writing after the close frame.
+	ErrCloseSent = errors.New("websocket: close frame has been sent")
+	// ErrBadHandshake is returned when the opening handshake fails.
+	ErrBadHandshake = errors.New("websocket: bad handshake")
+)
+
+// CloseError is returned by ReadMessage when the peer closes the connection,
+// or when the connection is failed because of the peer.
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Text)
+}
+
+// Conn is a WebSocket connection.
+// one goroutine may read while others write, the writes are serialized.
+type Conn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	isServer    bool
+	subprotocol string
+	compress    bool
+
+	readLimit    int64
+	fragmentSize int
+	lastRead     int64
+	readErr      error
+
+	writeLock sync.Mutex
+	closeSent bool
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newConn(conn net.Conn, br *bufio.Reader, isServer bool, subprotocol string, compress bool) *Conn {
+	if br == nil {
+		br = bufio.NewReader(conn)
+	} // if>
+	c := &Conn{conn: conn, br: br, isServer: isServer, subprotocol: subprotocol, compress: compress, done: make(chan struct{})}
+	c.touch()
+	return c
+}
+
+// Subprotocol returns the negotiated subprotocol.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// Compressed reports whether permessage-deflate has been negotiated.
+func (c *Conn) Compressed() bool {
+	return c.compress
+}
+
+// RemoteAddr returns the address of the peer.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// SetReadLimit sets the max size in bytes of a message read from the peer,
+// the connection is closed with CloseMessageTooBig beyond it.
+// 0 means DefaultReadLimit, a message is never read without a limit.
+func (c *Conn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+func (c *Conn) limit() int64 {
+	if c.readLimit > 0 {
+		return c.readLimit
+	} // if>
+	return DefaultReadLimit
+}
+
+// SetFragmentSize splits the messages larger than size into fragments when writing.
+// 0 means no fragmentation.
+func (c *Conn) SetFragmentSize(size int) {
+	c.fragmentSize = size
+}
+
+// SetReadDeadline sets the deadline of reading from the underlying connection.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline of writing to the underlying connection.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// Done is closed when the underlying connection is closed.
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// $--- reading ---
+
+// ReadMessage reads the next text or binary message,
+// the fragments are joined and the ping, pong and close frames are handled.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	if c.readErr != nil {
+		return 0, nil, c.readErr
+	} // if>
+
+	messageType, compressed := 0, false
+	var message []byte
+	for {
+		fin, rsv1, op, payload, err := c.readFrame(c.limit() - int64(len(message)))
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			c.closeConn()
+			err = &CloseError{Code: CloseAbnormalClosure, Text: err.Error()}
+		} // if>>
+		if err != nil {
+			return 0, nil, c.failRead(err)
+		} // if>>
+
+		switch op {
+		case PingMessage:
+			if err := c.WriteControl(PongMessage, payload); err != nil && err != ErrCloseSent {
+				return 0, nil, c.failRead(err)
+			} // if>>>
+			continue
+		case PongMessage:
+			continue
+		case CloseMessage:
+			return 0, nil, c.failRead(c.handleClose(payload))
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, c.failRead(c.fail(CloseProtocolError, "new message in a fragmented message"))
+			} // if>>>
+			messageType, compressed = op, rsv1
+		case continuationFrame:
+			if messageType == 0 || rsv1 {
+				return 0, nil, c.failRead(c.fail(CloseProtocolError, "unexpected continuation frame"))
+			} // if>>>
+		default:
+			return 0, nil, c.failRead(c.fail(CloseProtocolError, fmt.Sprintf("unknown opcode %d", op)))
+		} // switch>>
+
+		message = append(message, payload...)
+		if fin {
+			break
+		} // if>>
+	} // for>
+
+	if compressed {
+		var err error
+		if message, err = c.decompress(message); err != nil {
+			return 0, nil, c.failRead(err)
+		} // if>>
+	} // if>
+	if messageType == TextMessage && !utf8.Valid(message) {
+		return 0, nil, c.failRead(c.fail(CloseInvalidFramePayloadData, "invalid utf-8"))
+	} // if>
+	return messageType, message, nil
+}
+
+// readFrame reads a frame and checks its header,
+// a data frame larger than the budget left by the read limit fails.
+func (c *Conn) readFrame(budget int64) (fin bool, rsv1 bool, op int, payload []byte, err error) {
+	var head [14]byte
+	if _, err = io.ReadFull(c.br, head[:2]); err != nil {
+		return
+	} // if>
+	c.touch()
+	fin, rsv1, op = head[0]&finalBit != 0, head[0]&rsv1Bit != 0, int(head[0]&0x0f)
+	masked, length := head[1]&maskBit != 0, int64(head[1]&0x7f)
+
+	if head[0]&rsv23Bit != 0 || (rsv1 && (!c.compress || op >= CloseMessage)) {
+		return false, false, 0, nil, c.fail(CloseProtocolError, "unexpected reserved bits")
+	} // if>
+	if masked != c.isServer {
+		return false, false, 0, nil, c.fail(CloseProtocolError, "bad mask")
+	} // if>
+	if op >= CloseMessage && (!fin || length > maxControlPayload) {
+		return false, false, 0, nil, c.fail(CloseProtocolError, "bad control frame")
+	} // if>
+
+	switch length {
+	case 126:
+		if _, err = io.ReadFull(c.br, head[2:4]); err != nil {
+			return
+		} // if>>
+		length = int64(binary.BigEndian.Uint16(head[2:4]))
+	case 127:
+		if _, err = io.ReadFull(c.br, head[2:10]); err != nil {
+			return
+		} // if>>
+		length = int64(binary.BigEndian.Uint64(head[2:10]))
+		if length < 0 {
+			return false, false, 0, nil, c.fail(CloseProtocolError, "bad length")
+		} // if>>
+	} // switch>
+	// checked before anything is allocated for the length claimed by the peer
+	if op < CloseMessage && length > budget {
+		c.fail(CloseMessageTooBig, "")
+		return false, false, 0, nil, ErrReadLimit
+	} // if>
+
+	var key [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.br, key[:]); err != nil {
+			return
+		} // if>>
+	} // if>
+	// the buffer grows with the data really sent
+	var buf bytes.Buffer
+	if length <= 4096 {
+		buf.Grow(int(length))
+	} // if>
+	if _, err = io.CopyN(&buf, c.br, length); err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	} // if>
+	if err != nil {
+		return
+	} // if>
+	payload = buf.Bytes()
+	if masked {
+		maskBytes(key, payload)
+	} // if>
+	return fin, rsv1, op, payload, nil
+}
+
+// handleClose answers the close frame of the peer and closes the connection.
+func (c *Conn) handleClose(payload []byte) error {
+	code, text := CloseNoStatusReceived, ""
+	switch {
+	case len(payload) == 1:
+		return c.fail(CloseProtocolError, "bad close frame")
+	case len(payload) >= 2:
+		code, text = int(binary.BigEndian.Uint16(payload)), string(payload[2:])
+		if !validCloseCode(code) || !utf8.ValidString(text) {
+			return c.fail(CloseProtocolError, "bad close frame")
+		} // if>>
+	} // switch>
+
+	reply := []byte{}
+	if code != CloseNoStatusReceived {
+		reply = payload[:2]
+	} // if>
+	_ = c.WriteControl(CloseMessage, reply)
+	c.closeConn()
+	return &CloseError{Code: code, Text: text}
+}
+
+func (c *Conn) failRead(err error) error {
+	c.readErr = err
+	return err
+}
+
+// fail closes the connection with the code because of the peer.
+func (c *Conn) fail(code int, text string) error {
+	_ = c.WriteControl(CloseMessage, FormatCloseMessage(code, text))
+	c.closeConn()
+	return &CloseError{Code: code, Text: text}
+}
+
+func (c *Conn) touch() {
+	atomic.StoreInt64(&c.lastRead, time.Now().UnixNano())
+}
+
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011:
+		return true
+	default:
+		return code >= 3000 && code <= 4999
+	} // switch>
+}
+
+// $--- writing ---
+
+// WriteMessage writes a text or binary message,
+// it is compressed if negotiated and split by the fragment size.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return fmt.Errorf("websocket: bad message type %d", messageType)
+	} // if>
+	compressed := c.compress
+	if compressed {
+		var err error
+		if data, err = compress(data); err != nil {
+			return err
+		} // if>>
+	} // if>
+
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	if c.closeSent {
+		return ErrCloseSent
+	} // if>
+	op := messageType
+	for {
+		chunk, fin := data, true
+		if c.fragmentSize > 0 && len(data) > c.fragmentSize {
+			chunk, fin = data[:c.fragmentSize], false
+		} // if>>
+		if err := c.writeFrame(fin, compressed, op, chunk); err != nil {
+			return err
+		} // if>>
+		if fin {
+			return nil
+		} // if>>
+		data, op, compressed = data[len(chunk):], continuationFrame, false
+	} // for>
+}
+
+// WriteControl writes a ping, pong or close frame,
+// the connection is closed after a close frame.
+func (c *Conn) WriteControl(messageType int, data []byte) error {
+	if messageType < CloseMessage || messageType > PongMessage || len(data) > maxControlPayload {
+		return fmt.Errorf("websocket: bad control frame %d", messageType)
+	} // if>
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	if c.closeSent {
+		return ErrCloseSent
+	} // if>
+	if messageType == CloseMessage {
+		c.closeSent = true
+	} // if>
+	return c.writeFrame(true, false, messageType, data)
+}
+
+// Ping writes a ping frame.
+func (c *Conn) Ping(data []byte) error {
+	return c.WriteControl(PingMessage, data)
+}
+
+// Close sends a close frame with the code and closes the connection.
+func (c *Conn) Close(code int, text string) error {
+	err := c.WriteControl(CloseMessage, FormatCloseMessage(code, text))
+	c.closeConn()
+	if err == ErrCloseSent {
+		return nil
+	} // if>
+	return err
+}
+
+// KeepAlive pings the peer every interval until the connection is closed,
+// the connection is closed when nothing is read from the peer in two intervals.
+// the pongs are read by ReadMessage, so the connection must be read meanwhile.
+func (c *Conn) KeepAlive(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if time.Since(time.Unix(0, atomic.LoadInt64(&c.lastRead))) > 2*interval {
+					_ = c.Close(CloseGoingAway, "keepalive timeout")
+					return
+				} // if>>>>
+				if c.Ping(nil) != nil {
+					return
+				} // if>>>>
+			case <-c.done:
+				return
+			} // select>>>
+		} // for>>
+	}()
+}
+
+func (c *Conn) writeFrame(fin bool, rsv1 bool, op int, payload []byte) error {
+	frame := make([]byte, 0, len(payload)+14)
+	b0 := byte(op)
+	if fin {
+		b0 |= finalBit
+	} // if>
+	if rsv1 {
+		b0 |= rsv1Bit
+	} // if>
+	var b1 byte
+	if !c.isServer {
+		b1 = maskBit
+	} // if>
+	switch n := len(payload); {
+	case n <= maxControlPayload:
+		frame = append(frame, b0, b1|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, b0, b1|126, 0, 0)
+		binary.BigEndian.PutUint16(frame[2:], uint16(n))
+	default:
+		frame = append(frame, b0, b1|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(frame[2:], uint64(n))
+	} // switch>
+
+	if c.isServer {
+		frame = append(frame, payload...)
+	} else {
+		var key [4]byte
+		if _, err := rand.Read(key[:]); err != nil {
+			return err
+		} // if>>
+		frame = append(frame, key[:]...)
+		start := len(frame)
+		frame = append(frame, payload...)
+		maskBytes(key, frame[start:])
+	} // else>
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+func (c *Conn) closeConn() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		_ = c.conn.Close()
+	})
+}
+
+// FormatCloseMessage returns the payload of a close frame.
+func FormatCloseMessage(code int, text string) []byte {
+	if code == CloseNoStatusReceived {
+		return []byte{}
+	} // if>
+	payload := make([]byte, 2+len(text))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	copy(payload[2:], text)
+	if len(payload) > maxControlPayload {
+		payload = payload[:maxControlPayload]
+	} // if>
+	return payload
+}
+
+func maskBytes(key [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= key[i&3]
+	} // for>
+}
+
+// $--- permessage-deflate ---
+
+// the tail removed from every compressed message, and an empty final block
+// to end the stream without an unexpected EOF.
+var deflateTail = []byte{0x00, 0x00, 0xff, 0xff, 0x01, 0x00, 0x00, 0xff, 0xff}
+
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	fw, err := flate.NewWriter(&buf, flate.BestSpeed)
+	if err != nil {
+		return nil, err
+	} // if>
+	if _, err := fw.Write(data); err != nil {
+		return nil, err
+	} // if>
+	if err := fw.Flush(); err != nil {
+		return nil, err
+	} // if>
+	return bytes.TrimSuffix(buf.Bytes(), deflateTail[:4]), nil
+}
+
+func (c *Conn) decompress(data []byte) ([]byte, error) {
+	fr := flate.NewReader(io.MultiReader(bytes.NewReader(data), bytes.NewReader(deflateTail)))
+	defer fr.Close()
+	message, err := ioutil.ReadAll(io.LimitReader(fr, c.limit()+1))
+	if err != nil {
+		return nil, c.fail(CloseInvalidFramePayloadData, "bad compressed data")
+	} // if>
+	if int64(len(message)) > c.limit() {
+		c.fail(CloseMessageTooBig, "")
+		return nil, ErrReadLimit
+	} // if>
+	return message, nil
+}
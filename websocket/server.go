@@ -0,0 +1,172 @@
+package websocket
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Upgrader upgrades HTTP requests to WebSocket connections.
+type Upgrader struct {
+	// Subprotocols are the supported subprotocols in order of preference.
+	Subprotocols []string
+	// EnableCompression negotiates permessage-deflate if the client offers it.
+	EnableCompression bool
+	// ReadLimit is the read limit of the connections, 0 means DefaultReadLimit.
+	ReadLimit int64
+	// CheckOrigin returns whether the origin of the request is accepted,
+	// by default only the requests without Origin or from the same host are.
+	CheckOrigin func(r *http.Request) bool
+}
+
+// Upgrade runs the opening handshake and takes over the connection of w,
+// the error response is written to w if the request is not accepted.
+func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, header http.Header) (*Conn, error) {
+	if r.Method != http.MethodGet {
+		return u.reject(w, http.StatusMethodNotAllowed, "request method is not GET")
+	} // if>
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		return u.reject(w, http.StatusBadRequest, "not a websocket handshake")
+	} // if>
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return u.reject(w, http.StatusUpgradeRequired, "unsupported version")
+	} // if>
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return u.reject(w, http.StatusBadRequest, "bad Sec-WebSocket-Key")
+	} // if>
+	checkOrigin := u.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	} // if>
+	if !checkOrigin(r) {
+		return u.reject(w, http.StatusForbidden, "origin not allowed")
+	} // if>
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return u.reject(w, http.StatusInternalServerError, "response does not implement http.Hijacker")
+	} // if>
+
+	subprotocol := ""
+	offered := headerTokens(r.Header, "Sec-WebSocket-Protocol")
+	for _, p := range u.Subprotocols {
+		if containsToken(offered, p) {
+			subprotocol = p
+			break
+		} // if>>
+	} // for>
+	compress := u.EnableCompression && offersDeflate(r.Header)
+
+	conn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	} // if>
+	if brw.Reader.Buffered() > 0 {
+		_ = conn.Close()
+		return nil, ErrBadHandshake
+	} // if>
+
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: ")
+	b.WriteString(acceptKey(key))
+	b.WriteString("\r\n")
+	if subprotocol != "" {
+		b.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	} // if>
+	if compress {
+		b.WriteString("Sec-WebSocket-Extensions: permessage-deflate; server_no_context_takeover; client_no_context_takeover\r\n")
+	} // if>
+	// Header.Write drops the invalid names and the CR and LF of the values
+	_ = header.Write(&b)
+	b.WriteString("\r\n")
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		_ = conn.Close()
+		return nil, err
+	} // if>
+
+	c := newConn(conn, brw.Reader, true, subprotocol, compress)
+	c.SetReadLimit(u.ReadLimit)
+	return c, nil
+}
+
+func (u *Upgrader) reject(w http.ResponseWriter, code int, reason string) (*Conn, error) {
+	http.Error(w, http.StatusText(code), code)
+	return nil, &HandshakeError{Code: code, Reason: reason}
+}
+
+// HandshakeError is returned by Upgrade when the request is not accepted.
+type HandshakeError struct {
+	Code   int
+	Reason string
+}
+
+func (e *HandshakeError) Error() string {
+	return "websocket: " + e.Reason
+}
+
+// Unwrap makes errors.Is(err, ErrBadHandshake) true.
+func (e *HandshakeError) Unwrap() error {
+	return ErrBadHandshake
+}
+
+// IsWebSocketUpgrade reports whether r asks for a websocket upgrade.
+func IsWebSocketUpgrade(r *http.Request) bool {
+	return headerContains(r.Header, "Connection", "upgrade") && headerContains(r.Header, "Upgrade", "websocket")
+}
+
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	} // if>
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// headerTokens returns the comma separated tokens of the header.
+func headerTokens(header http.Header, name string) []string {
+	var tokens []string
+	for _, v := range header[http.CanonicalHeaderKey(name)] {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tokens = append(tokens, t)
+			} // if>>>
+		} // for>>
+	} // for>
+	return tokens
+}
+
+func headerContains(header http.Header, name, token string) bool {
+	return containsToken(headerTokens(header, name), token)
+}
+
+func containsToken(tokens []string, token string) bool {
+	for _, t := range tokens {
+		if strings.EqualFold(t, token) {
+			return true
+		} // if>>
+	} // for>
+	return false
+}
+
+// offersDeflate reports whether permessage-deflate is in the extensions of header,
+// the parameters are ignored since no context takeover is answered.
+func offersDeflate(header http.Header) bool {
+	for _, ext := range headerTokens(header, "Sec-WebSocket-Extensions") {
+		if strings.EqualFold(strings.TrimSpace(strings.Split(ext, ";")[0]), "permessage-deflate") {
+			return true
+		} // if>>
+	} // for>
+	return false
+}
@@ -0,0 +1,250 @@
+package websocket
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serve starts a server upgrading with u and handing the connections to h,
+// the error returned by h is sent to the returned channel.
+func serve(t *testing.T, u *Upgrader, h func(c *Conn) error) (string, chan error, func()) {
+	errs := make(chan error, 1)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := u.Upgrade(w, r, nil)
+		if err != nil {
+			errs <- err
+			return
+		} // if>>
+		errs <- h(c)
+	}))
+	return "ws" + strings.TrimPrefix(s.URL, "http"), errs, s.Close
+}
+
+func echo(c *Conn) error {
+	for {
+		messageType, data, err := c.ReadMessage()
+		if err != nil {
+			return err
+		} // if>>
+		if err := c.WriteMessage(messageType, data); err != nil {
+			return err
+		} // if>>
+	} // for>
+}
+
+func closeCode(err error) int {
+	var ce *CloseError
+	if errors.As(err, &ce) {
+		return ce.Code
+	} // if>
+	return 0
+}
+
+func TestEcho(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		url, errs, stop := serve(t, &Upgrader{Subprotocols: []string{"chat", "json"}, EnableCompression: true}, echo)
+		d := &Dialer{Subprotocols: []string{"json", "chat"}, EnableCompression: compress}
+		c, _, err := d.Dial(url, nil)
+		if err != nil {
+			t.Fatal(err)
+		} // if>>
+		if c.Subprotocol() != "chat" || c.Compressed() != compress {
+			t.Fatalf("expected subprotocol chat and compression %v, got %q %v", compress, c.Subprotocol(), c.Compressed())
+		} // if>>
+
+		c.SetFragmentSize(100)
+		long := bytes.Repeat([]byte("tong "), 1000)
+		for _, m := range []struct {
+			messageType int
+			data        []byte
+		}{{TextMessage, []byte("hello")}, {BinaryMessage, []byte{0, 1, 2}}, {TextMessage, long}, {BinaryMessage, []byte{}}} {
+			if err := c.WriteMessage(m.messageType, m.data); err != nil {
+				t.Fatal(err)
+			} // if>>>
+			messageType, data, err := c.ReadMessage()
+			if err != nil || messageType != m.messageType || !bytes.Equal(data, m.data) {
+				t.Fatalf("compress %v: expected echo of %d bytes, got %d bytes %v", compress, len(m.data), len(data), err)
+			} // if>>>
+		} // for>>
+
+		if err := c.Close(4000, "bye"); err != nil {
+			t.Fatal(err)
+		} // if>>
+		if err := <-errs; closeCode(err) != 4000 || err.(*CloseError).Text != "bye" {
+			t.Fatalf("expected close 4000 bye, got %v", err)
+		} // if>>
+		stop()
+	} // for>
+}
+
+func TestPingPong(t *testing.T) {
+	url, errs, stop := serve(t, &Upgrader{}, func(c *Conn) error {
+		if err := c.Ping([]byte("are you there")); err != nil {
+			return err
+		} // if>>
+		// the pong is handled by ReadMessage, then the close of the client comes
+		_, _, err := c.ReadMessage()
+		return err
+	})
+	defer stop()
+	c, _, err := Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	} // if>
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = c.Close(CloseNormalClosure, "")
+	}()
+	// ReadMessage answers the ping and fails on the closed connection
+	if _, _, err := c.ReadMessage(); err == nil {
+		t.Fatal("expected an error after close")
+	} // if>
+	if err := <-errs; closeCode(err) != CloseNormalClosure {
+		t.Fatalf("expected normal closure, got %v", err)
+	} // if>
+}
+
+func TestKeepAliveTimeout(t *testing.T) {
+	url, errs, stop := serve(t, &Upgrader{}, func(c *Conn) error {
+		c.KeepAlive(10 * time.Millisecond)
+		go func() { _, _, _ = c.ReadMessage() }()
+		select {
+		case <-c.Done():
+			return nil
+		case <-time.After(time.Second):
+			return errors.New("connection kept alive without pongs")
+		} // select>>
+	})
+	defer stop()
+	// the client never reads, so the pings are never answered
+	c, _, err := Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	} // if>
+	defer c.Close(CloseNormalClosure, "")
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	} // if>
+}
+
+func TestReadLimit(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		url, errs, stop := serve(t, &Upgrader{ReadLimit: 16, EnableCompression: true}, echo)
+		c, _, err := (&Dialer{EnableCompression: compress}).Dial(url, nil)
+		if err != nil {
+			t.Fatal(err)
+		} // if>>
+		if err := c.WriteMessage(TextMessage, bytes.Repeat([]byte("a"), 64)); err != nil {
+			t.Fatal(err)
+		} // if>>
+		if err := <-errs; err != ErrReadLimit {
+			t.Fatalf("expected ErrReadLimit, got %v", err)
+		} // if>>
+		if _, _, err := c.ReadMessage(); closeCode(err) != CloseMessageTooBig {
+			t.Fatalf("expected close %d, got %v", CloseMessageTooBig, err)
+		} // if>>
+		stop()
+	} // for>
+}
+
+func TestHugeFrameLength(t *testing.T) {
+	// no ReadLimit still means DefaultReadLimit
+	url, errs, stop := serve(t, &Upgrader{}, echo)
+	defer stop()
+	c, _, err := Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	} // if>
+	header := []byte{finalBit | BinaryMessage, maskBit | 127, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 1, 2, 3, 4}
+	if _, err := c.conn.Write(header); err != nil {
+		t.Fatal(err)
+	} // if>
+	if err := <-errs; err != ErrReadLimit {
+		t.Fatalf("expected ErrReadLimit, got %v", err)
+	} // if>
+	if _, _, err := c.ReadMessage(); closeCode(err) != CloseMessageTooBig {
+		t.Fatalf("expected close %d, got %v", CloseMessageTooBig, err)
+	} // if>
+}
+
+func TestProtocolErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		send func(c *Conn) error
+		code int
+	}{
+		{"invalid utf-8", func(c *Conn) error { return c.WriteMessage(TextMessage, []byte{0xff, 0xfe}) }, CloseInvalidFramePayloadData},
+		{"unmasked frame", func(c *Conn) error {
+			c.isServer = true
+			return c.WriteMessage(TextMessage, []byte("hi"))
+		}, CloseProtocolError},
+		{"lone continuation", func(c *Conn) error {
+			c.writeLock.Lock()
+			defer c.writeLock.Unlock()
+			return c.writeFrame(true, false, continuationFrame, []byte("hi"))
+		}, CloseProtocolError},
+		{"bad close code", func(c *Conn) error { return c.WriteControl(CloseMessage, FormatCloseMessage(1004, "")) }, CloseProtocolError},
+	} {
+		url, errs, stop := serve(t, &Upgrader{}, echo)
+		c, _, err := Dial(url, nil)
+		if err != nil {
+			t.Fatal(err)
+		} // if>>
+		if err := tc.send(c); err != nil {
+			t.Fatal(err)
+		} // if>>
+		if err := <-errs; closeCode(err) != tc.code {
+			t.Fatalf("%s: expected close %d, got %v", tc.name, tc.code, err)
+		} // if>>
+		stop()
+	} // for>
+}
+
+func TestHandshake(t *testing.T) {
+	url, errs, stop := serve(t, &Upgrader{}, echo)
+	defer stop()
+
+	_, resp, err := Dial(url, http.Header{"Origin": {"http://evil.example"}})
+	if err != ErrBadHandshake || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected a forbidden origin, got %v", err)
+	} // if>
+	if err := <-errs; !errors.Is(err, ErrBadHandshake) {
+		t.Fatalf("expected a handshake error, got %v", err)
+	} // if>
+
+	resp, err = http.Get("http" + strings.TrimPrefix(url, "ws"))
+	if err != nil {
+		t.Fatal(err)
+	} // if>
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a plain request, got %d", resp.StatusCode)
+	} // if>
+	<-errs
+
+	// the header values can not add lines to the handshake response
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := http.Header{"X-Room": {"lobby\r\nSet-Cookie: sid=evil"}, "Bad\r\nName": {"x"}}
+		if c, err := (&Upgrader{}).Upgrade(w, r, header); err == nil {
+			_ = c.Close(CloseNormalClosure, "")
+		} // if>>
+	}))
+	defer s.Close()
+	c, resp, err := Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	} // if>
+	_ = c.Close(CloseNormalClosure, "")
+	if resp.Header.Get("Set-Cookie") != "" || resp.Header.Get("X-Room") != "lobby  Set-Cookie: sid=evil" {
+		t.Fatalf("unexpected handshake header %v", resp.Header)
+	} // if>
+
+	if got := acceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected accept key %q", got)
+	} // if>
+}
@@ -0,0 +1,53 @@
+package tong
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ming3000/tong/common"
+	"github.com/ming3000/tong/websocket"
+)
+
+func TestWebSocket(t *testing.T) {
+	tong := newTestTong()
+	var log bytes.Buffer
+	tong.Logger = common.NewWriterLogger(&log, "", false)
+	tong.WebSocket("/ws/:room", func(c *Context, ws *websocket.Conn) error {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return err
+		} // if>
+		return ws.WriteMessage(websocket.TextMessage, []byte(c.Param("room")+": "+string(data)))
+	}, traceMiddleware("ws"))
+	s := httptest.NewServer(tong)
+	defer s.Close()
+	url := "ws" + strings.TrimPrefix(s.URL, "http") + "/ws/lobby"
+
+	ws, resp, err := websocket.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	} // if>
+	if resp.Header.Get("X-Trace") != "ws" {
+		t.Fatalf("expected the middleware to run before the upgrade")
+	} // if>
+	if err := ws.WriteMessage(websocket.TextMessage, []byte("hi")); err != nil {
+		t.Fatal(err)
+	} // if>
+	if _, data, err := ws.ReadMessage(); err != nil || string(data) != "lobby: hi" {
+		t.Fatalf("unexpected message %q %v", data, err)
+	} // if>
+	// the connection is closed normally when the handler returns
+	if _, _, err := ws.ReadMessage(); err == nil || err.(*websocket.CloseError).Code != websocket.CloseNormalClosure {
+		t.Fatalf("expected a normal closure, got %v", err)
+	} // if>
+
+	if _, resp, err := websocket.Dial(url, http.Header{"Origin": {"http://evil.example"}}); err == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected the cross origin handshake to be forbidden, got %v", err)
+	} // if>
+	if log.Len() != 0 {
+		t.Fatalf("the rejected handshake is logged as an error: %s", log.String())
+	} // if>
+}